@@ -0,0 +1,17 @@
+// configctl 校验、渲染、比较配置文件，读写nacos配置，与服务使用相同的解析、校验规则
+//  e.g.
+//  configctl validate -env prod app.yaml
+//  configctl render -env prod -o json app.yaml
+//  configctl diff -addr 127.0.0.1:8848 -data-id app.yaml -group DEFAULT_GROUP app.yaml
+//  configctl pull -addr 127.0.0.1:8848 -data-id app.yaml -group DEFAULT_GROUP -out app.yaml
+//  configctl push -addr 127.0.0.1:8848 -data-id app.yaml -group DEFAULT_GROUP -md5 <md5> app.yaml
+package main
+
+import (
+	"config/configctl"
+	"os"
+)
+
+func main() {
+	os.Exit(configctl.Main(os.Args[1:]))
+}
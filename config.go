@@ -3,34 +3,99 @@ package config
 import (
 	"config/internal"
 	. "config/primitive"
+	"context"
+	"time"
 )
 
 type IConfig interface {
 	DailNacos(addr, namespace string, opts ...ClientOption) error
+	UseNacosClient(client INacosClient, namespace string) error
+	Health() Health
+	Ready(ctx context.Context, name string) error
 
-	RegisterFile(file string, v interface{}) error
-	RegisterFileWithName(name, file string, v interface{}) error
+	RegisterFile(file string, v interface{}, opts ...RegisterOption) error
+	RegisterFileWithName(name, file string, v interface{}, opts ...RegisterOption) error
 
-	RegisterMixed(file, dataID, group string, v IMixedConfig) error
-	RegisterMixedWithName(name, file, dataID, group string, v IMixedConfig) error
+	RegisterMixed(file, dataID, group string, v interface{}, opts ...RegisterOption) error
+	RegisterMixedWithName(name, file, dataID, group string, v interface{}, opts ...RegisterOption) error
 
 	RegisterNacos(dataID, group string) error
 	RegisterNacosWithName(name, dataID, group string) error
 
+	RegisterNacosStruct(dataID, group string, v interface{}, opts ...RegisterOption) error
+	RegisterNacosStructWithName(name, dataID, group string, v interface{}, opts ...RegisterOption) error
+
+	RegisterLayered(v interface{}, layers []Layer, opts ...RegisterOption) error
+	RegisterLayeredWithName(name string, v interface{}, layers []Layer, opts ...RegisterOption) error
+
 	GetConfig() interface{}
 	GetFileConfig() interface{}
 	GetNacosConfig() interface{}
 	GetMixedConfig() interface{}
+	GetLayeredConfig() interface{}
 
 	GetConfigByName(name string) interface{}
 	GetFileConfigByName(name string) interface{}
 	GetNacosConfigByName(name string) interface{}
 	GetMixedConfigByName(name string) interface{}
+	GetLayeredConfigByName(name string) interface{}
 
 	GetConfigWithFlag() (interface{}, Flag)
 	GetConfigWithFlagByName(name string) (interface{}, Flag)
+
+	GetString(path string) (string, error)
+	GetInt(path string) (int, error)
+	GetBool(path string) (bool, error)
+	GetDuration(path string) (time.Duration, error)
+	GetStringSlice(path string) ([]string, error)
+	GetStringMap(path string) (map[string]interface{}, error)
+
+	GetStringByName(name, path string) (string, error)
+	GetIntByName(name, path string) (int, error)
+	GetBoolByName(name, path string) (bool, error)
+	GetDurationByName(name, path string) (time.Duration, error)
+	GetStringSliceByName(name, path string) ([]string, error)
+	GetStringMapByName(name, path string) (map[string]interface{}, error)
+
+	Explain(name, path string) (Explanation, error)
+
+	OnError(h ErrorHandler)
+	Failures() []UpdateFailure
+
+	Subscribe(name string, h ChangeHandler) func()
+	Watch(ctx context.Context, name string) <-chan Change
+
+	Publish(name string, value interface{}, opts ...PublishOption) error
+	Delete(name string, opts ...PublishOption) error
+
+	Unregister(name string) error
+	UnregisterNacos(dataID, group string) error
+	Close(ctx context.Context) error
+}
+
+// NewConfig 创建配置实例
+//  e.g.
+//  c := NewConfig(WithNacosClient(client, namespace))      // 使用已创建的nacos客户端，无需调用DailNacos
+//  c := NewConfig(WithLogger(l), WithLogDir("/var/log"))   // DailNacos创建客户端时使用的选项
+func NewConfig(opts ...ConfigOption) IConfig {
+	return internal.NewConfigIns(opts...)
+}
+
+// RegisterCodec 注册配置格式的编解码器，format为文件扩展名（不含"."）
+//  内置yaml(yml)、json、toml、env，注册同名格式时覆盖内置实现
+func RegisterCodec(format string, codec Codec) {
+	internal.RegisterCodec(format, codec)
+}
+
+// LookupCodec 获取已注册格式的编解码器，格式未注册时返回ErrUnknownFormat
+func LookupCodec(format string) (Codec, error) {
+	return internal.LookupCodec(format)
 }
 
-func NewConfig() IConfig {
-	return internal.NewConfigIns()
+// NewNacosClient 创建nacos客户端，创建前解析地址中的域名，不访问服务端
+//  e.g.
+//  client, err := NewNacosClient(addr, namespace, WithAccessKey(accessKey), WithSecretKey(secretKey))
+//  c := NewConfig(WithNacosClient(client, namespace))
+func NewNacosClient(addr, namespace string, opts ...ClientOption) (INacosClient, error) {
+	return internal.NewNacosClient(addr, namespace, opts...)
 }
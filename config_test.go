@@ -158,6 +158,17 @@ var _ = Describe("Api", func() {
 			Expect(flag == primitive.OnlyFile).Should(BeTrue())
 		})
 
+		It("profile", func() {
+			c := config.NewConfig()
+
+			err := c.RegisterFile("demo.yaml", &Configure{}, primitive.WithProfile(""))
+			Expect(err).Should(Succeed())
+
+			conf := c.GetFileConfig().(*Configure)
+			Expect(conf.ProductName == "config").Should(BeTrue())
+			Expect(conf.Redis.Host == "127.0.0.1").Should(BeTrue())
+		})
+
 		It("no pointer type", func() {
 			c := config.NewConfig()
 
@@ -0,0 +1,404 @@
+package configctl
+
+import (
+	"config"
+	. "config/primitive"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// name 注册配置时使用的名称
+const name = "configctl"
+
+// parseFlags 解析配置文件、nacos内容的参数，与注册选项对应
+type parseFlags struct {
+	schema    string
+	env       string
+	profile   bool
+	envPrefix string
+}
+
+func (p *parseFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&p.schema, "schema", "", "通过RegisterSchema注册的结构体名称，为空时只校验格式")
+	fs.StringVar(&p.env, "env", "", "按照cur_env/envs结构解析指定环境的配置")
+	fs.BoolVar(&p.profile, "profile", false, "按照cur_env/envs结构解析，环境由环境变量"+DefaultProfileEnv+"或cur_env指定")
+	fs.StringVar(&p.envPrefix, "env-prefix", "", "使用该前缀的环境变量覆盖字段，e.g. APP")
+}
+
+// options 对应的注册选项
+func (p *parseFlags) options() []RegisterOption {
+	var opts []RegisterOption
+	if p.env != "" || p.profile {
+		opts = append(opts, WithProfile(p.env))
+	}
+
+	if p.envPrefix != "" {
+		opts = append(opts, WithEnv(p.envPrefix))
+	}
+
+	return opts
+}
+
+// nacosFlags 连接nacos及dataID、group的参数，连接参数默认读取NACOS_*环境变量
+type nacosFlags struct {
+	addr      string
+	namespace string
+	accessKey string
+	secretKey string
+	username  string
+	password  string
+
+	dataID string
+	group  string
+	format string
+}
+
+func (n *nacosFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&n.addr, "addr", os.Getenv("NACOS_ADDR"), "nacos地址，默认为环境变量NACOS_ADDR")
+	fs.StringVar(&n.namespace, "namespace", os.Getenv("NACOS_NAMESPACE"), "nacos namespace，默认为环境变量NACOS_NAMESPACE")
+	fs.StringVar(&n.accessKey, "access-key", os.Getenv("NACOS_ACCESS_KEY"), "默认为环境变量NACOS_ACCESS_KEY")
+	fs.StringVar(&n.secretKey, "secret-key", os.Getenv("NACOS_SECRET_KEY"), "默认为环境变量NACOS_SECRET_KEY")
+	fs.StringVar(&n.username, "username", os.Getenv("NACOS_USERNAME"), "默认为环境变量NACOS_USERNAME")
+	fs.StringVar(&n.password, "password", os.Getenv("NACOS_PASSWORD"), "默认为环境变量NACOS_PASSWORD")
+	fs.StringVar(&n.dataID, "data-id", "", "nacos dataID")
+	fs.StringVar(&n.group, "group", "", "nacos group")
+	fs.StringVar(&n.format, "format", "", "nacos内容的格式，默认按照dataID的扩展名，无法识别时为yaml")
+}
+
+// check 检查必需的参数
+func (n *nacosFlags) check(c *ctl) error {
+	if n.dataID == "" || n.group == "" {
+		return ErrEmptyDataIDOrGroup
+	}
+
+	if c.client == nil && n.addr == "" {
+		return errors.New("nacos address is empty, use -addr or NACOS_ADDR")
+	}
+
+	return nil
+}
+
+// connect 创建nacos客户端，WithClient时使用指定的客户端
+//  日志、缓存写入临时目录并在结束后删除，避免服务端不可用时读取到之前的缓存
+func (n *nacosFlags) connect(c *ctl) (INacosClient, string, func(), error) {
+	if c.client != nil {
+		return c.client, c.namespace, func() {}, nil
+	}
+
+	dir, err := ioutil.TempDir("", "configctl")
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	opts := []ClientOption{
+		constant.WithLogDir(dir),
+		constant.WithCacheDir(dir),
+		constant.WithLogStdout(false),
+		WithLogLevel("error"),
+	}
+
+	if n.accessKey != "" || n.secretKey != "" {
+		opts = append(opts, WithAccessKey(n.accessKey), WithSecretKey(n.secretKey))
+	}
+
+	if n.username != "" || n.password != "" {
+		opts = append(opts, WithUserName(n.username), WithPassword(n.password))
+	}
+
+	client, err := config.NewNacosClient(n.addr, n.namespace, opts...)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, "", nil, err
+	}
+
+	return client, n.namespace, func() { os.RemoveAll(dir) }, nil
+}
+
+// validate 按照结构体解析并校验配置文件，全部通过时返回ExitOK
+func (c *ctl) validate(args []string) (int, error) {
+	var p parseFlags
+	fs := c.flagSet("validate")
+	p.bind(fs)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage, nil
+	}
+
+	if fs.NArg() == 0 {
+		return ExitUsage, errors.New("missing file")
+	}
+
+	code := ExitOK
+	for _, file := range fs.Args() {
+		if _, err := loadFile(file, &p); err != nil {
+			fmt.Fprintf(c.stdout, "FAIL %s: %v\n", file, err)
+			code = ExitFail
+			continue
+		}
+
+		fmt.Fprintf(c.stdout, "ok   %s\n", file)
+	}
+
+	return code, nil
+}
+
+// render 输出生效的配置，包括default tag、选择的环境及环境变量覆盖
+func (c *ctl) render(args []string) (int, error) {
+	var p parseFlags
+	fs := c.flagSet("render")
+	p.bind(fs)
+	out := fs.String("o", "yaml", "输出格式，yaml、json、toml、env或通过RegisterCodec注册的格式")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage, nil
+	}
+
+	if fs.NArg() != 1 {
+		return ExitUsage, errors.New("need exactly one file")
+	}
+
+	codec, err := config.LookupCodec(*out)
+	if err != nil {
+		return ExitUsage, err
+	}
+
+	tree, err := loadFile(fs.Arg(0), &p)
+	if err != nil {
+		return ExitFail, err
+	}
+
+	data, err := codec.Marshal(tree)
+	if err != nil {
+		return ExitFail, err
+	}
+
+	c.stdout.Write(data)
+	return ExitOK, nil
+}
+
+// diff 比较两个配置文件；指定-data-id时比较nacos配置与配置文件。存在差异时返回ExitFail
+func (c *ctl) diff(args []string) (int, error) {
+	var (
+		p parseFlags
+		n nacosFlags
+	)
+
+	fs := c.flagSet("diff")
+	p.bind(fs)
+	n.bind(fs)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage, nil
+	}
+
+	var (
+		left, right map[string]interface{}
+		from, to    string
+		err         error
+	)
+
+	switch {
+	case n.dataID != "" && fs.NArg() == 1:
+		if err = n.check(c); err != nil {
+			return ExitUsage, err
+		}
+
+		from, to = fmt.Sprintf("nacos:%s/%s", n.group, n.dataID), fs.Arg(0)
+		if left, err = c.loadNacos(&n, &p); err != nil {
+			return ExitFail, err
+		}
+
+	case n.dataID == "" && fs.NArg() == 2:
+		from, to = fs.Arg(0), fs.Arg(1)
+		if left, err = loadFile(from, &p); err != nil {
+			return ExitFail, err
+		}
+
+	default:
+		return ExitUsage, errors.New("need two files, or one file with -data-id")
+	}
+
+	if right, err = loadFile(to, &p); err != nil {
+		return ExitFail, err
+	}
+
+	changes := diffTree(left, right)
+	if len(changes) == 0 {
+		return ExitOK, nil
+	}
+
+	fmt.Fprintf(c.stdout, "--- %s\n+++ %s\n", from, to)
+	for _, line := range changes {
+		fmt.Fprintln(c.stdout, line)
+	}
+
+	return ExitFail, nil
+}
+
+// push 校验配置文件后将原始内容写入nacos，-md5指定时服务端内容的md5一致才写入
+func (c *ctl) push(args []string) (int, error) {
+	var (
+		p parseFlags
+		n nacosFlags
+	)
+
+	fs := c.flagSet("push")
+	p.bind(fs)
+	n.bind(fs)
+	expected := fs.String("md5", "", "服务端当前内容的md5，不一致时不写入，可通过pull获取")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage, nil
+	}
+
+	if fs.NArg() != 1 {
+		return ExitUsage, errors.New("need exactly one file")
+	}
+
+	if err := n.check(c); err != nil {
+		return ExitUsage, err
+	}
+
+	file := fs.Arg(0)
+	if _, err := loadFile(file, &p); err != nil {
+		return ExitFail, err
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ExitFail, err
+	}
+
+	client, _, done, err := n.connect(c)
+	if err != nil {
+		return ExitFail, err
+	}
+	defer done()
+
+	param := vo.ConfigParam{DataId: n.dataID, Group: n.group}
+	if *expected != "" {
+		current, err := client.GetConfig(param)
+		if err != nil {
+			return ExitFail, err
+		}
+
+		if sum := checksum(current); sum != *expected {
+			return ExitFail, fmt.Errorf("%w: %s/%s, expected %s, current %s", ErrConflict, n.group, n.dataID, *expected, sum)
+		}
+	}
+
+	param.Content = string(data)
+	ok, err := client.PublishConfig(param)
+	if err == nil && !ok {
+		err = ErrPublishFailed
+	}
+
+	if err != nil {
+		return ExitFail, err
+	}
+
+	fmt.Fprintf(c.stdout, "pushed %s/%s md5 %s\n", n.group, n.dataID, checksum(param.Content))
+	return ExitOK, nil
+}
+
+// pull 读取nacos配置的原始内容，写入-out指定的文件或标准输出，md5输出到标准错误
+func (c *ctl) pull(args []string) (int, error) {
+	var n nacosFlags
+	fs := c.flagSet("pull")
+	n.bind(fs)
+	out := fs.String("out", "", "写入的文件，默认为标准输出")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage, nil
+	}
+
+	if err := n.check(c); err != nil {
+		return ExitUsage, err
+	}
+
+	client, _, done, err := n.connect(c)
+	if err != nil {
+		return ExitFail, err
+	}
+	defer done()
+
+	content, err := client.GetConfig(vo.ConfigParam{DataId: n.dataID, Group: n.group})
+	if err != nil {
+		return ExitFail, err
+	}
+
+	if content == "" {
+		return ExitFail, fmt.Errorf("%w: %s/%s", ErrNotExistConfig, n.group, n.dataID)
+	}
+
+	if *out != "" {
+		err = ioutil.WriteFile(*out, []byte(content), 0644)
+	} else {
+		_, err = fmt.Fprint(c.stdout, content)
+	}
+
+	if err != nil {
+		return ExitFail, err
+	}
+
+	fmt.Fprintf(c.stderr, "md5 %s\n", checksum(content))
+	return ExitOK, nil
+}
+
+// loadFile 按照注册配置文件的规则解析，返回生效配置的树
+func loadFile(file string, p *parseFlags) (map[string]interface{}, error) {
+	v, err := schema(p.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	c := config.NewConfig()
+	if err = c.RegisterFileWithName(name, file, v, p.options()...); err != nil {
+		return nil, err
+	}
+
+	return c.GetStringMapByName(name, "")
+}
+
+// loadNacos 按照注册nacos配置的规则解析，配置不存在时为空
+func (c *ctl) loadNacos(n *nacosFlags, p *parseFlags) (map[string]interface{}, error) {
+	v, err := schema(p.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	client, namespace, done, err := n.connect(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	opts := p.options()
+	if n.format != "" {
+		opts = append(opts, WithFormat(n.format))
+	}
+
+	cfg := config.NewConfig(WithNacosClient(client, namespace))
+	defer cfg.Close(context.Background())
+
+	err = cfg.RegisterNacosStructWithName(name, n.dataID, n.group, v, opts...)
+	if errors.Is(err, ErrNotExistConfig) {
+		return map[string]interface{}{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.GetStringMapByName(name, "")
+}
+
+// checksum 内容的md5，与nacos服务端计算方式一致
+func checksum(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
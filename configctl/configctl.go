@@ -0,0 +1,148 @@
+// Package configctl configctl命令行工具的实现，与服务使用相同的解析、校验规则
+//  内置的cmd/configctl没有注册结构体，只校验格式；需要按照结构体校验时，在自己的main中注册后调用Main
+//  e.g.
+//  func main() {
+//  	configctl.RegisterSchema("app", &AppConfig{})
+//  	os.Exit(configctl.Main(os.Args[1:]))
+//  }
+package configctl
+
+import (
+	. "config/primitive"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 退出码
+const (
+	ExitOK    = 0 // 成功
+	ExitFail  = 1 // 校验失败、存在差异或执行失败
+	ExitUsage = 2 // 参数错误
+)
+
+var (
+	schemaMutex sync.RWMutex
+	schemas     = map[string]interface{}{}
+)
+
+// RegisterSchema 注册结构体，通过-schema name指定，v为结构体指针
+func RegisterSchema(name string, v interface{}) {
+	schemaMutex.Lock()
+	schemas[name] = v
+	schemaMutex.Unlock()
+}
+
+// schema 获取注册的结构体，name为空时解析为map
+func schema(name string) (interface{}, error) {
+	if name == "" {
+		return &map[string]interface{}{}, nil
+	}
+
+	schemaMutex.RLock()
+	defer schemaMutex.RUnlock()
+
+	v, exist := schemas[name]
+	if !exist {
+		names := make([]string, 0, len(schemas))
+		for n := range schemas {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("%w: schema %s, registered: [%s]", ErrNotRegistered, name, strings.Join(names, ", "))
+	}
+
+	return v, nil
+}
+
+// Option Main的可选项
+type Option func(*ctl)
+
+// WithOutput 输出及错误信息的目标，默认为os.Stdout、os.Stderr
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(c *ctl) {
+		c.stdout, c.stderr = stdout, stderr
+	}
+}
+
+// WithClient 使用已创建的nacos客户端，忽略-addr等连接参数
+func WithClient(client INacosClient, namespace string) Option {
+	return func(c *ctl) {
+		c.client, c.namespace = client, namespace
+	}
+}
+
+type ctl struct {
+	stdout    io.Writer
+	stderr    io.Writer
+	client    INacosClient
+	namespace string
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(c *ctl, args []string) (int, error)
+}
+
+var commands = []command{
+	{"validate", "validate [flags] file...     按照结构体解析并校验配置文件", (*ctl).validate},
+	{"render", "render [flags] file           输出生效的配置（默认值、环境、环境变量覆盖后）", (*ctl).render},
+	{"diff", "diff [flags] file1 [file2]     比较两个配置文件，或配置文件与nacos配置（指定-data-id时）", (*ctl).diff},
+	{"push", "push [flags] file             校验后将配置文件写入nacos", (*ctl).push},
+	{"pull", "pull [flags]                  读取nacos配置", (*ctl).pull},
+}
+
+// Main 执行configctl命令，args不包含程序名称，返回退出码
+func Main(args []string, opts ...Option) int {
+	c := &ctl{stdout: os.Stdout, stderr: os.Stderr}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		return ExitUsage
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		code, err := cmd.run(c, args[1:])
+		if err != nil {
+			fmt.Fprintf(c.stderr, "configctl %s: %v\n", cmd.name, err)
+		}
+
+		return code
+	}
+
+	fmt.Fprintf(c.stderr, "configctl: unknown command %s\n", args[0])
+	c.usage()
+	return ExitUsage
+}
+
+// flagSet 创建子命令的参数集合，参数错误时由flag输出错误及用法
+func (c *ctl) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("configctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// usage 输出命令列表
+func (c *ctl) usage() {
+	fmt.Fprintln(c.stderr, "usage: configctl <command> [flags]")
+	fmt.Fprintln(c.stderr)
+	for _, cmd := range commands {
+		fmt.Fprintln(c.stderr, "  "+cmd.usage)
+	}
+
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "configctl <command> -h 查看命令的参数")
+}
@@ -0,0 +1,13 @@
+package configctl_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigctl(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Configctl Suite")
+}
@@ -0,0 +1,179 @@
+package configctl_test
+
+import (
+	"bytes"
+	"config/configctl"
+	"config/configtest"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type AppConfig struct {
+	Name  string `yaml:"name" validate:"required"`
+	Port  int    `yaml:"port" default:"8080" validate:"min=1,max=65535"`
+	Level string `yaml:"level" default:"info" validate:"oneof=debug info warn error"`
+}
+
+const profileContent = `cur_env: dev
+envs:
+  dev:
+    name: app
+    level: debug
+  prod:
+    name: app
+    port: 80
+`
+
+func init() {
+	configctl.RegisterSchema("app", &AppConfig{})
+}
+
+var _ = Describe("Configctl", func() {
+	const (
+		dataID = "app.yaml"
+		group  = "config"
+	)
+
+	var (
+		dir            string
+		stdout, stderr *bytes.Buffer
+		client         *configtest.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "configctl")
+		Expect(err).Should(Succeed())
+
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+		client = configtest.NewClient()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).Should(Succeed())
+		return file
+	}
+
+	run := func(args ...string) int {
+		stdout.Reset()
+		stderr.Reset()
+		return configctl.Main(args, configctl.WithOutput(stdout, stderr), configctl.WithClient(client, client.Namespace()))
+	}
+
+	checksum := func(content string) string {
+		sum := md5.Sum([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	It("usage", func() {
+		Expect(run() == configctl.ExitUsage).Should(BeTrue())
+		Expect(stderr.String()).Should(ContainSubstring("validate"))
+		Expect(run("none") == configctl.ExitUsage).Should(BeTrue())
+		Expect(run("render", "-none") == configctl.ExitUsage).Should(BeTrue())
+	})
+
+	It("validate", func() {
+		good := writeFile("good.yaml", "name: app")
+		bad := writeFile("bad.yaml", "level: trace")
+
+		Expect(run("validate", good) == configctl.ExitOK).Should(BeTrue())
+		Expect(run("validate", "-schema", "app", good, bad) == configctl.ExitFail).Should(BeTrue())
+		Expect(stdout.String()).Should(ContainSubstring("ok   " + good))
+		Expect(stdout.String()).Should(ContainSubstring("FAIL " + bad))
+		Expect(stdout.String()).Should(ContainSubstring("level"))
+
+		// 只校验格式
+		Expect(run("validate", bad) == configctl.ExitOK).Should(BeTrue())
+		Expect(run("validate", writeFile("broken.yaml", "name: [")) == configctl.ExitFail).Should(BeTrue())
+
+		Expect(run("validate", "-schema", "none", good) == configctl.ExitFail).Should(BeTrue())
+		Expect(stdout.String()).Should(ContainSubstring("registered: [app]"))
+	})
+
+	It("render", func() {
+		file := writeFile("profile.yaml", profileContent)
+
+		Expect(run("render", "-schema", "app", "-env", "prod", file) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String() == "level: info\nname: app\nport: 80\n").Should(BeTrue())
+
+		Expect(run("render", "-schema", "app", "-profile", "-o", "json", file) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String()).Should(MatchJSON(`{"name": "app", "port": 8080, "level": "debug"}`))
+
+		defer os.Unsetenv("CTL_PORT")
+		os.Setenv("CTL_PORT", "9090")
+		Expect(run("render", "-schema", "app", "-env", "prod", "-env-prefix", "ctl", "-o", "env", file) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String()).Should(ContainSubstring("PORT=9090"))
+
+		Expect(run("render", "-o", "xml", file) == configctl.ExitUsage).Should(BeTrue())
+	})
+
+	It("diff", func() {
+		a := writeFile("a.yaml", "name: app\nlevel: debug\nhosts: [a, b]")
+		b := writeFile("b.yaml", "name: app\nport: 80\nhosts: [a]")
+
+		Expect(run("diff", a, a) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String() == "").Should(BeTrue())
+
+		Expect(run("diff", a, b) == configctl.ExitFail).Should(BeTrue())
+		Expect(stdout.String()).Should(Equal("--- " + a + "\n+++ " + b + "\n" +
+			"- hosts: [\"a\",\"b\"]\n+ hosts: [\"a\"]\n- level: \"debug\"\n+ port: 80\n"))
+
+		// 按照结构体解析后比较生效的值
+		Expect(run("diff", "-schema", "app", a, b) == configctl.ExitFail).Should(BeTrue())
+		Expect(stdout.String()).Should(ContainSubstring("- port: 8080\n+ port: 80"))
+
+		// nacos配置不存在时为空
+		Expect(run("diff", "-data-id", dataID, "-group", group, a) == configctl.ExitFail).Should(BeTrue())
+		Expect(stdout.String()).Should(HavePrefix("--- nacos:config/app.yaml\n+++ " + a))
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "name: app\nlevel: debug\nhosts: [a, b]"})
+		Expect(run("diff", "-data-id", dataID, "-group", group, a) == configctl.ExitOK).Should(BeTrue())
+
+		Expect(run("diff", a) == configctl.ExitUsage).Should(BeTrue())
+		Expect(run("diff", "-data-id", dataID, a) == configctl.ExitUsage).Should(BeTrue())
+	})
+
+	It("push & pull", func() {
+		file := writeFile("app.yaml", "name: app")
+		Expect(run("push", "-schema", "app", "-data-id", dataID, "-group", group, file) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String()).Should(ContainSubstring(checksum("name: app")))
+
+		Expect(run("pull", "-data-id", dataID, "-group", group) == configctl.ExitOK).Should(BeTrue())
+		Expect(stdout.String() == "name: app").Should(BeTrue())
+		Expect(stderr.String() == "md5 "+checksum("name: app")+"\n").Should(BeTrue())
+
+		out := filepath.Join(dir, "pulled.yaml")
+		Expect(run("pull", "-data-id", dataID, "-group", group, "-out", out) == configctl.ExitOK).Should(BeTrue())
+		data, err := ioutil.ReadFile(out)
+		Expect(err).Should(Succeed())
+		Expect(string(data) == "name: app").Should(BeTrue())
+
+		// 校验失败、md5不一致时不写入
+		invalid := writeFile("invalid.yaml", "port: 0")
+		Expect(run("push", "-schema", "app", "-data-id", dataID, "-group", group, invalid) == configctl.ExitFail).Should(BeTrue())
+
+		changed := writeFile("changed.yaml", "name: changed")
+		Expect(run("push", "-md5", checksum("other"), "-data-id", dataID, "-group", group, changed) == configctl.ExitFail).Should(BeTrue())
+		Expect(stderr.String()).Should(ContainSubstring("changed since last seen"))
+
+		Expect(run("push", "-md5", checksum("name: app"), "-data-id", dataID, "-group", group, changed) == configctl.ExitOK).Should(BeTrue())
+		content, _ := client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(content == "name: changed").Should(BeTrue())
+
+		Expect(run("pull", "-data-id", "none", "-group", group) == configctl.ExitFail).Should(BeTrue())
+		Expect(run("pull", "-data-id", dataID) == configctl.ExitUsage).Should(BeTrue())
+	})
+})
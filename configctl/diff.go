@@ -0,0 +1,76 @@
+package configctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// diffTree 比较两棵配置树的叶子节点，按照路径排序
+//  "- path: value"只在left中，"+ path: value"只在right中，值不同时依次输出两行
+func diffTree(left, right map[string]interface{}) []string {
+	l, r := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", left, l)
+	flatten("", right, r)
+
+	paths := make([]string, 0, len(l)+len(r))
+	for path := range l {
+		paths = append(paths, path)
+	}
+
+	for path := range r {
+		if _, exist := l[path]; !exist {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	var lines []string
+	for _, path := range paths {
+		lv, inLeft := l[path]
+		rv, inRight := r[path]
+		if inLeft && inRight && reflect.DeepEqual(lv, rv) {
+			continue
+		}
+
+		if inLeft {
+			lines = append(lines, fmt.Sprintf("- %s: %s", path, format(lv)))
+		}
+
+		if inRight {
+			lines = append(lines, fmt.Sprintf("+ %s: %s", path, format(rv)))
+		}
+	}
+
+	return lines
+}
+
+// flatten 将树展开为路径到叶子节点的映射，路径以"."分隔；slice、空map作为叶子节点
+func flatten(prefix string, node interface{}, leaves map[string]interface{}) {
+	m, ok := node.(map[string]interface{})
+	if !ok || (len(m) == 0 && prefix != "") {
+		leaves[prefix] = node
+		return
+	}
+
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		flatten(path, v, leaves)
+	}
+}
+
+// format 叶子节点的输出格式，无法序列化时按照%v输出
+func format(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,305 @@
+package configtest
+
+import (
+	. "config/primitive"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+var _ INacosClient = (*Client)(nil)
+
+type listenFunc = func(namespace, group, dataId, data string)
+
+// listener 一个监听及其异步触发的队列
+type listener struct {
+	fn      listenFunc
+	queue   chan string // 异步模式下待触发的内容，按照发布顺序由一个协程依次执行
+	pending []string    // queue写满时暂存的内容，避免在持有锁时阻塞
+	stopped bool        // 已取消监听，已入队的内容触发完成后结束协程
+	closed  bool        // queue已关闭
+}
+
+// Client 内存实现的nacos配置客户端，用于离线测试
+//  与服务端一致，配置内容发生变化时才触发监听，删除配置时以空内容触发；
+//  默认在PublishConfig中同步触发，指定WithAsync()时在新的协程中按发布顺序触发，可通过Wait等待
+type Client struct {
+	mutex     sync.Mutex
+	namespace string
+	async     bool
+	wg        sync.WaitGroup
+
+	contents  map[string]string
+	listeners map[string][]*listener
+	closed    bool
+}
+
+// Option Client的可选项
+type Option func(*Client)
+
+// WithNamespace 触发监听时传入的namespace，需要与注册时的namespace一致
+func WithNamespace(namespace string) Option {
+	return func(c *Client) {
+		c.namespace = namespace
+	}
+}
+
+// WithAsync 在新的协程中触发监听，与nacos客户端的行为一致
+//  同一个监听按照发布的顺序触发
+func WithAsync() Option {
+	return func(c *Client) {
+		c.async = true
+	}
+}
+
+// NewClient 创建内存实现的nacos配置客户端
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		contents:  make(map[string]string),
+		listeners: make(map[string][]*listener),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Namespace 触发监听时传入的namespace
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// GetConfig 读取配置，不存在时返回空内容
+func (c *Client) GetConfig(param vo.ConfigParam) (string, error) {
+	if err := checkParam(param.DataId, param.Group); err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.contents[key(param.DataId, param.Group)], nil
+}
+
+// PublishConfig 发布配置，内容发生变化时触发监听
+func (c *Client) PublishConfig(param vo.ConfigParam) (bool, error) {
+	if err := checkParam(param.DataId, param.Group); err != nil {
+		return false, err
+	}
+
+	if param.Content == "" {
+		return false, errors.New("[client.PublishConfig] param.content can not be empty")
+	}
+
+	c.set(param.DataId, param.Group, param.Content)
+	return true, nil
+}
+
+// DeleteConfig 删除配置，配置存在时以空内容触发监听
+func (c *Client) DeleteConfig(param vo.ConfigParam) (bool, error) {
+	if err := checkParam(param.DataId, param.Group); err != nil {
+		return false, err
+	}
+
+	c.set(param.DataId, param.Group, "")
+	return true, nil
+}
+
+// ListenConfig 监听配置变化
+func (c *Client) ListenConfig(param vo.ConfigParam) error {
+	if err := checkParam(param.DataId, param.Group); err != nil {
+		return err
+	}
+
+	if param.OnChange == nil {
+		return errors.New("[client.ListenConfig] param.onChange can not be nil")
+	}
+
+	k := key(param.DataId, param.Group)
+
+	l := &listener{fn: param.OnChange}
+
+	c.mutex.Lock()
+	if c.async {
+		l.queue = make(chan string, 1)
+		go c.serve(l, param.DataId, param.Group)
+	}
+	c.listeners[k] = append(c.listeners[k], l)
+	c.mutex.Unlock()
+	return nil
+}
+
+// CancelListenConfig 取消dataID和group的所有监听
+func (c *Client) CancelListenConfig(param vo.ConfigParam) error {
+	k := key(param.DataId, param.Group)
+
+	c.mutex.Lock()
+	for _, l := range c.listeners[k] {
+		l.stop()
+	}
+	delete(c.listeners, k)
+	c.mutex.Unlock()
+	return nil
+}
+
+// SearchConfig 按照dataID、group精确查找配置，为空时不作为查询条件
+func (c *Client) SearchConfig(param vo.SearchConfigParam) (*model.ConfigPage, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	page := &model.ConfigPage{PageNumber: 1, PagesAvailable: 1}
+	for k, content := range c.contents {
+		dataID, group := split(k)
+		if (param.DataId != "" && param.DataId != dataID) || (param.Group != "" && param.Group != group) {
+			continue
+		}
+
+		page.PageItems = append(page.PageItems, model.ConfigItem{DataId: dataID, Group: group, Content: content, Tenant: c.namespace})
+	}
+
+	page.TotalCount = len(page.PageItems)
+	return page, nil
+}
+
+// PublishAggr 等同于PublishConfig
+func (c *Client) PublishAggr(param vo.ConfigParam) (bool, error) {
+	return c.PublishConfig(param)
+}
+
+// CloseClient 标记客户端已关闭
+func (c *Client) CloseClient() {
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+}
+
+// Closed 是否已调用CloseClient
+func (c *Client) Closed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.closed
+}
+
+// Listening dataID和group当前的监听数量
+func (c *Client) Listening(dataID, group string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.listeners[key(dataID, group)])
+}
+
+// Wait 等待异步触发的监听执行完成
+func (c *Client) Wait() {
+	c.wg.Wait()
+}
+
+// set 修改配置内容，内容发生变化时触发监听
+func (c *Client) set(dataID, group, content string) {
+	k := key(dataID, group)
+
+	c.mutex.Lock()
+	old, exist := c.contents[k]
+	if content == "" {
+		delete(c.contents, k)
+	} else {
+		c.contents[k] = content
+	}
+
+	if (exist && old == content) || (!exist && content == "") {
+		c.mutex.Unlock()
+		return
+	}
+
+	listeners := append([]*listener{}, c.listeners[k]...)
+	if c.async {
+		// 在锁内入队，并发发布时触发的顺序与内容修改的顺序一致
+		c.wg.Add(len(listeners))
+		for _, l := range listeners {
+			l.enqueue(content)
+		}
+	}
+	c.mutex.Unlock()
+
+	if c.async {
+		return
+	}
+
+	for _, l := range listeners {
+		l.fn(c.namespace, group, dataID, content)
+	}
+}
+
+// enqueue 将内容加入异步触发的队列，需要持有c.mutex
+func (l *listener) enqueue(content string) {
+	if len(l.pending) == 0 {
+		select {
+		case l.queue <- content:
+			return
+		default:
+		}
+	}
+
+	l.pending = append(l.pending, content)
+}
+
+// serve 依次执行监听的异步触发，每个监听一个协程
+func (c *Client) serve(l *listener, dataID, group string) {
+	for content := range l.queue {
+		l.fn(c.namespace, group, dataID, content)
+		c.wg.Done()
+
+		c.mutex.Lock()
+		if len(l.pending) > 0 {
+			select {
+			case l.queue <- l.pending[0]:
+				l.pending = l.pending[1:]
+			default:
+			}
+		}
+
+		l.closeIfDrained()
+		c.mutex.Unlock()
+	}
+}
+
+// stop 取消监听，需要持有c.mutex
+func (l *listener) stop() {
+	if l.queue == nil {
+		return
+	}
+
+	l.stopped = true
+	l.closeIfDrained()
+}
+
+// closeIfDrained 取消监听且没有暂存的内容时关闭queue，需要持有c.mutex
+func (l *listener) closeIfDrained() {
+	if l.stopped && !l.closed && len(l.pending) == 0 {
+		l.closed = true
+		close(l.queue)
+	}
+}
+
+func checkParam(dataID, group string) error {
+	if dataID == "" {
+		return errors.New("param.dataId can not be empty")
+	}
+
+	if group == "" {
+		return errors.New("param.group can not be empty")
+	}
+
+	return nil
+}
+
+func key(dataID, group string) string {
+	return dataID + "@@" + group
+}
+
+func split(k string) (string, string) {
+	parts := strings.SplitN(k, "@@", 2)
+	return parts[0], parts[1]
+}
@@ -0,0 +1,146 @@
+package configtest_test
+
+import (
+	"config"
+	"config/configtest"
+	"config/primitive"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type MongoConf struct {
+	Host        string `yaml:"host"`
+	MaxPoolSize uint64 `yaml:"max_pool_size"`
+}
+
+var _ = Describe("Client", func() {
+	const (
+		dataID = "mongo"
+		group  = "config"
+	)
+
+	It("listen", func() {
+		client := configtest.NewClient(configtest.WithNamespace("test"))
+
+		var contents []string
+		err := client.ListenConfig(vo.ConfigParam{DataId: dataID, Group: group, OnChange: func(namespace, group, dataId, data string) {
+			Expect(namespace == "test").Should(BeTrue())
+			contents = append(contents, data)
+		}})
+		Expect(err).Should(Succeed())
+		Expect(client.Listening(dataID, group) == 1).Should(BeTrue())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		client.DeleteConfig(vo.ConfigParam{DataId: dataID, Group: group})
+
+		// 内容未变化时不触发，删除时以空内容触发
+		Expect(contents).Should(Equal([]string{"host: a", "host: b", ""}))
+
+		content, err := client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(err).Should(Succeed())
+		Expect(content == "").Should(BeTrue())
+
+		Expect(client.CancelListenConfig(vo.ConfigParam{DataId: dataID, Group: group})).Should(Succeed())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+	})
+
+	It("async", func() {
+		client := configtest.NewClient(configtest.WithAsync())
+
+		var count int32
+		client.ListenConfig(vo.ConfigParam{DataId: dataID, Group: group, OnChange: func(namespace, group, dataId, data string) {
+			atomic.AddInt32(&count, 1)
+		}})
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.Wait()
+		Expect(atomic.LoadInt32(&count) == 1).Should(BeTrue())
+	})
+
+	It("async order", func() {
+		client := configtest.NewClient(configtest.WithAsync())
+
+		var mutex sync.Mutex
+		var contents []string
+		client.ListenConfig(vo.ConfigParam{DataId: dataID, Group: group, OnChange: func(namespace, group, dataId, data string) {
+			// 第一次触发较慢，后续的内容不能先于它触发
+			if data == "0" {
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			mutex.Lock()
+			contents = append(contents, data)
+			mutex.Unlock()
+		}})
+
+		var expected []string
+		for i := 0; i < 20; i++ {
+			expected = append(expected, strconv.Itoa(i))
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: strconv.Itoa(i)})
+		}
+
+		client.Wait()
+		Expect(contents).Should(Equal(expected))
+
+		// 取消监听后不再触发
+		Expect(client.CancelListenConfig(vo.ConfigParam{DataId: dataID, Group: group})).Should(Succeed())
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.Wait()
+		Expect(len(contents) == 20).Should(BeTrue())
+	})
+
+	It("invalid param", func() {
+		client := configtest.NewClient()
+
+		_, err := client.GetConfig(vo.ConfigParam{Group: group})
+		Expect(err).Should(HaveOccurred())
+
+		_, err = client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(err).Should(HaveOccurred())
+
+		err = client.ListenConfig(vo.ConfigParam{DataId: dataID})
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("search", func() {
+		client := configtest.NewClient()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.PublishConfig(vo.ConfigParam{DataId: "redis", Group: group, Content: "host: b"})
+
+		page, err := client.SearchConfig(vo.SearchConfigParam{Group: group})
+		Expect(err).Should(Succeed())
+		Expect(page.TotalCount == 2).Should(BeTrue())
+
+		page, err = client.SearchConfig(vo.SearchConfigParam{DataId: "redis"})
+		Expect(err).Should(Succeed())
+		Expect(page.PageItems).Should(HaveLen(1))
+		Expect(page.PageItems[0].Content == "host: b").Should(BeTrue())
+	})
+
+	It("with config", func() {
+		client := configtest.NewClient()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a\nmax_pool_size: 10"})
+
+		c := config.NewConfig(primitive.WithNacosClient(client, client.Namespace()))
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "a").Should(BeTrue())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b\nmax_pool_size: 10"})
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "b").Should(BeTrue())
+
+		// 注入的客户端由调用方释放
+		Expect(c.Close(context.Background())).Should(Succeed())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+		Expect(client.Closed()).Should(BeFalse())
+	})
+})
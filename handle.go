@@ -0,0 +1,104 @@
+package config
+
+import (
+	. "config/primitive"
+	"fmt"
+)
+
+// Handle 类型安全的配置句柄，通过RegisterFile、RegisterNacos、RegisterMixed、RegisterLayered或Lookup获取
+type Handle[T any] struct {
+	c    IConfig
+	name string
+	flag Flag
+}
+
+// Name 配置名称
+func (h *Handle[T]) Name() string {
+	return h.name
+}
+
+// Flag 配置的模式
+func (h *Handle[T]) Flag() Flag {
+	return h.flag
+}
+
+// Get 获取当前生效的配置，外部禁止修改；需要修改时注册时指定WithDeepCopy()
+func (h *Handle[T]) Get() *T {
+	var v interface{}
+	switch h.flag {
+	case OnlyFile:
+		v = h.c.GetFileConfigByName(h.name)
+	case OnlyNacos:
+		v = h.c.GetNacosConfigByName(h.name)
+	case Mixed:
+		v = h.c.GetMixedConfigByName(h.name)
+	case Layered:
+		v = h.c.GetLayeredConfigByName(h.name)
+	}
+
+	conf, _ := v.(*T)
+	return conf
+}
+
+// Publish 将配置写入注册的nacos dataID和group，只支持RegisterNacos、RegisterMixed获取的句柄
+func (h *Handle[T]) Publish(v *T, opts ...PublishOption) error {
+	return h.c.Publish(h.name, v, opts...)
+}
+
+// RegisterFile 注册配置文件，返回类型为T的配置句柄
+//  e.g.
+//  h, err := config.RegisterFile[YourConfig](c, "app", "app.yaml")
+//  conf := h.Get()
+func RegisterFile[T any](c IConfig, name, file string, opts ...RegisterOption) (*Handle[T], error) {
+	if err := c.RegisterFileWithName(name, file, new(T), opts...); err != nil {
+		return nil, err
+	}
+
+	return &Handle[T]{c: c, name: name, flag: OnlyFile}, nil
+}
+
+// RegisterNacos 注册nacos dataID和group，配置内容解析为T，返回类型为T的配置句柄
+func RegisterNacos[T any](c IConfig, name, dataID, group string, opts ...RegisterOption) (*Handle[T], error) {
+	if err := c.RegisterNacosStructWithName(name, dataID, group, new(T), opts...); err != nil {
+		return nil, err
+	}
+
+	return &Handle[T]{c: c, name: name, flag: OnlyNacos}, nil
+}
+
+// RegisterMixed 注册可更新配置，返回类型为T的配置句柄；*T实现IMixedConfig中的方法时会被调用
+//  e.g.
+//  h, err := config.RegisterMixed[YourConfig](c, "app", "app.yaml", dataID, group, WithMerge(MergeField))
+func RegisterMixed[T any](c IConfig, name, file, dataID, group string, opts ...RegisterOption) (*Handle[T], error) {
+	if err := c.RegisterMixedWithName(name, file, dataID, group, new(T), opts...); err != nil {
+		return nil, err
+	}
+
+	return &Handle[T]{c: c, name: name, flag: Mixed}, nil
+}
+
+// RegisterLayered 注册分层配置，返回类型为T的配置句柄
+//  e.g.
+//  h, err := config.RegisterLayered[YourConfig](c, "app", []Layer{DefaultsLayer(defaults), FileLayer("app.yaml"), EnvLayer("APP")})
+func RegisterLayered[T any](c IConfig, name string, layers []Layer, opts ...RegisterOption) (*Handle[T], error) {
+	if err := c.RegisterLayeredWithName(name, new(T), layers, opts...); err != nil {
+		return nil, err
+	}
+
+	return &Handle[T]{c: c, name: name, flag: Layered}, nil
+}
+
+// Lookup 获取已注册配置的句柄，读取顺序与GetConfigWithFlagByName一致
+//  配置不存在时返回ErrNotRegistered，类型不是*T时返回ErrTypeMismatch
+func Lookup[T any](c IConfig, name string) (*Handle[T], error) {
+	v, flag := c.GetConfigWithFlagByName(name)
+	if v == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+	}
+
+	if _, ok := v.(*T); !ok {
+		return nil, fmt.Errorf("%w: %s is %T, not %T", ErrTypeMismatch, name, v, new(T))
+	}
+
+	return &Handle[T]{c: c, name: name, flag: flag}, nil
+}
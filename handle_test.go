@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"config"
+	"config/primitive"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handle", func() {
+	It("file mode", func() {
+		c := config.NewConfig()
+
+		h, err := config.RegisterFile[GeneralConfig](c, "app", "demo.yaml")
+		Expect(err).Should(Succeed())
+		Expect(h.Name() == "app").Should(BeTrue())
+		Expect(h.Flag() == primitive.OnlyFile).Should(BeTrue())
+
+		conf := h.Get()
+		Expect(conf.Configs["local"].ProductName == "config").Should(BeTrue())
+		Expect(conf == c.GetFileConfigByName("app")).Should(BeTrue())
+
+		_, err = config.RegisterFile[GeneralConfig](c, "app", "demo.yaml")
+		Expect(err).Should(Equal(primitive.ErrAlreadyRegister))
+	})
+
+	It("profile", func() {
+		c := config.NewConfig()
+
+		h, err := config.RegisterFile[Configure](c, "app", "demo.yaml", primitive.WithProfile("local"))
+		Expect(err).Should(Succeed())
+		Expect(h.Get().Redis.Port == 6379).Should(BeTrue())
+	})
+
+	It("lookup", func() {
+		c := config.NewConfig()
+		Expect(c.RegisterFile("demo.yaml", &GeneralConfig{})).Should(Succeed())
+
+		h, err := config.Lookup[GeneralConfig](c, "default")
+		Expect(err).Should(Succeed())
+		Expect(h.Get().CurEnv == "local").Should(BeTrue())
+
+		_, err = config.Lookup[Configure](c, "default")
+		Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+
+		_, err = config.Lookup[GeneralConfig](c, "app")
+		Expect(errors.Is(err, primitive.ErrNotRegistered)).Should(BeTrue())
+	})
+
+	It("layered", func() {
+		c := config.NewConfig()
+
+		h, err := config.RegisterLayered[Configure](c, "app", []primitive.Layer{
+			primitive.DefaultsLayer(Configure{Port: 8080, LogLevel: "info"}),
+			primitive.FileLayer("demo.yaml"),
+		}, primitive.WithProfile("local"))
+		Expect(err).Should(Succeed())
+		Expect(h.Flag() == primitive.Layered).Should(BeTrue())
+		Expect(h.Get().Redis.Port == 6379).Should(BeTrue())
+		Expect(h.Get() == c.GetLayeredConfigByName("app")).Should(BeTrue())
+	})
+
+	It("mixed mode not dial nacos", func() {
+		c := config.NewConfig()
+
+		_, err := config.RegisterMixed[GeneralConfig](c, "app", "demo.yaml", dataID, group)
+		Expect(err).Should(Equal(primitive.ErrDialNacosFirst))
+	})
+})
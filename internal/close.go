@@ -0,0 +1,219 @@
+package internal
+
+import (
+	. "config/primitive"
+	"context"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// Unregister 移除name对应的所有模式的配置，停止文件监听；dataID和group不再被任何配置使用时取消nacos监听
+func (c *configIns) Unregister(name string) error {
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	s := c.load()
+	if s.closed {
+		return ErrClosed
+	}
+
+	fc, inFile := s.files[name]
+	nc, inNacos := s.nacos[name]
+	mc, inMixed := s.mixed[name]
+	lc, inLayered := s.layered[name]
+	if !inFile && !inNacos && !inMixed && !inLayered {
+		return ErrNotRegistered
+	}
+
+	var cancels []*listener
+	c.commit(func(s *snapshot) {
+		delete(s.files, name)
+
+		if inNacos {
+			delete(s.nacos, name)
+			if l := s.removeListener(nc.dataID, nc.group, name, OnlyNacos); l != nil {
+				cancels = append(cancels, l)
+			}
+		}
+
+		if inMixed {
+			delete(s.mixed, name)
+			if l := s.removeListener(mc.dataID, mc.group, name, Mixed); l != nil {
+				cancels = append(cancels, l)
+			}
+		}
+
+		if inLayered {
+			delete(s.layered, name)
+			for _, key := range lc.nacosKeys() {
+				if l := s.removeListener(key[0], key[1], name, Layered); l != nil {
+					cancels = append(cancels, l)
+				}
+			}
+		}
+	})
+
+	c.clearFailures(name, OnlyFile, OnlyNacos, Mixed, Layered)
+
+	var err error
+	if inFile {
+		err = fc.close(context.Background())
+	}
+
+	if inLayered {
+		if e := lc.close(context.Background()); err == nil {
+			err = e
+		}
+	}
+
+	for _, l := range cancels {
+		if e := cancelListen(s.client, l); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// UnregisterNacos 移除监听dataID和group的所有nacos、混合、分层模式配置，并取消监听
+//  分层配置的其他层也会一起移除
+func (c *configIns) UnregisterNacos(dataID, group string) error {
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	s := c.load()
+	if s.closed {
+		return ErrClosed
+	}
+
+	l, exist := s.listeners[listenKey(s.namespace, dataID, group)]
+	if !exist {
+		return ErrNotRegistered
+	}
+
+	cancels := []*listener{l}
+	var layered []*layeredConfig
+	c.commit(func(s *snapshot) {
+		for _, name := range l.nacos {
+			delete(s.nacos, name)
+		}
+
+		for _, name := range l.mixed {
+			delete(s.mixed, name)
+		}
+
+		delete(s.listeners, listenKey(s.namespace, dataID, group))
+
+		// 分层配置监听的其他dataID和group不再被使用时一起取消
+		for _, name := range l.layered {
+			lc := s.layered[name]
+			delete(s.layered, name)
+			layered = append(layered, lc)
+
+			for _, key := range lc.nacosKeys() {
+				if l := s.removeListener(key[0], key[1], name, Layered); l != nil {
+					cancels = append(cancels, l)
+				}
+			}
+		}
+	})
+
+	for _, name := range l.nacos {
+		c.clearFailures(name, OnlyNacos)
+	}
+
+	for _, name := range l.mixed {
+		c.clearFailures(name, Mixed)
+	}
+
+	for _, name := range l.layered {
+		c.clearFailures(name, Layered)
+	}
+
+	var err error
+	for _, lc := range layered {
+		if e := lc.close(context.Background()); err == nil {
+			err = e
+		}
+	}
+
+	for _, l := range cancels {
+		if e := cancelListen(s.client, l); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Close 取消所有nacos监听，停止文件监听，之后的注册返回ErrClosed
+//  已注册的配置仍可读取，但不再更新；ctx用于限制等待文件监听退出的时间
+//  只释放DailNacos创建且实现了ClientCloser的客户端，WithNacosClient、UseNacosClient注入的客户端由调用方释放；
+//  nacos-sdk-go v1.1.4的客户端没有提供关闭方法，Close后其长轮询协程仍会运行
+func (c *configIns) Close(ctx context.Context) error {
+	// 先停止重试及后台任务，再等待进行中的注册完成
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	s := c.load()
+	if s.closed {
+		return nil
+	}
+
+	c.commit(func(s *snapshot) {
+		s.listeners = make(map[string]*listener)
+		s.closed = true
+	})
+
+	var err error
+	for _, l := range s.listeners {
+		if e := cancelListen(s.client, l); err == nil {
+			err = e
+		}
+	}
+
+	for _, fc := range s.files {
+		if e := fc.close(ctx); err == nil {
+			err = e
+		}
+	}
+
+	for _, lc := range s.layered {
+		if e := lc.close(ctx); err == nil {
+			err = e
+		}
+	}
+
+	if closer, ok := s.client.(ClientCloser); ok && s.owned {
+		closer.CloseClient()
+	}
+
+	return err
+}
+
+// removeListener 移除监听dataID和group的配置名称，没有配置使用时删除并返回该监听
+func (s *snapshot) removeListener(dataID, group, name string, flag Flag) *listener {
+	key := listenKey(s.namespace, dataID, group)
+	l, exist := s.listeners[key]
+	if !exist {
+		return nil
+	}
+
+	l = l.remove(name, flag)
+	if l.refs() > 0 {
+		s.listeners[key] = l
+		return nil
+	}
+
+	delete(s.listeners, key)
+	return l
+}
+
+// cancelListen 取消nacos监听
+func cancelListen(client INacosClient, l *listener) error {
+	return client.CancelListenConfig(vo.ConfigParam{DataId: l.dataID, Group: l.group})
+}
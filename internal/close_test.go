@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Close", func() {
+	It("unregister", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+		Expect(c.RegisterNacosStructWithName("a", dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.RegisterMixedWithName("b", "mixed.yaml", dataID, group, &MongoConf{}, primitive.WithSharedListener())).Should(Succeed())
+
+		// 仍有配置使用时不取消监听
+		Expect(c.Unregister("a")).Should(Succeed())
+		Expect(c.GetNacosConfigByName("a")).Should(BeNil())
+		Expect(client.Listening(dataID, group) == 1).Should(BeTrue())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(c.GetMixedConfigByName("b").(*MongoConf).Host == "b").Should(BeTrue())
+
+		Expect(c.Unregister("b")).Should(Succeed())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+		Expect(c.Unregister("b")).Should(Equal(primitive.ErrNotRegistered))
+
+		// 取消后可以重新注册
+		Expect(c.RegisterNacosStructWithName("a", dataID, group, &MongoConf{})).Should(Succeed())
+	})
+
+	It("unregister file", func() {
+		dir, err := ioutil.TempDir("", "config")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "mongo.yaml")
+		Expect(ioutil.WriteFile(file, []byte("host: a"), 0644)).Should(Succeed())
+
+		c := NewConfigIns()
+		Expect(c.RegisterFile(file, &MongoConf{}, primitive.WithWatch())).Should(Succeed())
+		fc := c.load().files[defaultName]
+
+		Expect(c.Unregister(defaultName)).Should(Succeed())
+		Expect(fc.fw.done).Should(BeClosed())
+		Expect(c.GetFileConfig()).Should(BeNil())
+	})
+
+	It("unregister nacos", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+		Expect(c.RegisterNacosStructWithName("a", dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.RegisterMixedWithName("b", "mixed.yaml", dataID, group, &MongoConf{}, primitive.WithSharedListener())).Should(Succeed())
+
+		Expect(c.UnregisterNacos(dataID, group)).Should(Succeed())
+		Expect(c.GetNacosConfigByName("a")).Should(BeNil())
+		Expect(c.GetMixedConfigByName("b")).Should(BeNil())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+
+		Expect(c.UnregisterNacos(dataID, group)).Should(Equal(primitive.ErrNotRegistered))
+	})
+
+	It("close", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+		Expect(c.RegisterFile("mixed.yaml", &MongoConf{}, primitive.WithWatch())).Should(Succeed())
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+		Expect(c.Close(context.Background())).Should(Succeed())
+		Expect(client.Closed()).Should(BeFalse())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+		Expect(c.load().files[defaultName].fw.done).Should(BeClosed())
+
+		// 已注册的配置仍可读取
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "a").Should(BeTrue())
+		Expect(c.GetFileConfig()).ShouldNot(BeNil())
+
+		Expect(c.RegisterFileWithName("app", "mixed.yaml", &MongoConf{})).Should(Equal(primitive.ErrClosed))
+		Expect(c.RegisterNacosWithName("app", dataID, group)).Should(Equal(primitive.ErrClosed))
+		Expect(c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf{})).Should(Equal(primitive.ErrClosed))
+		Expect(c.Unregister(defaultName)).Should(Equal(primitive.ErrClosed))
+
+		Expect(c.Close(context.Background())).Should(Succeed())
+	})
+
+	It("close owned client", func() {
+		c, client := newTestIns()
+		c.commit(func(s *snapshot) {
+			s.owned = true
+		})
+
+		Expect(c.Close(context.Background())).Should(Succeed())
+		Expect(client.Closed()).Should(BeTrue())
+	})
+
+	It("close during retry", func() {
+		client := &flakyClient{Client: configtest.NewClient(), failures: 1 << 30}
+		c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithRetry(primitive.Backoff{Attempts: 10, Initial: time.Second}))
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- c.RegisterNacosStruct(dataID, group, &MongoConf{})
+		}()
+		Eventually(func() int32 { return atomic.LoadInt32(&client.calls) }).Should(BeNumerically(">=", 1))
+
+		// 重试期间不阻塞其他注册及Close
+		start := time.Now()
+		Expect(c.RegisterFileWithName("file", "mixed.yaml", &MongoConf{})).Should(Succeed())
+		Expect(c.Close(context.Background())).Should(Succeed())
+		Expect(time.Since(start) < time.Second).Should(BeTrue())
+
+		Eventually(errs).Should(Receive(HaveOccurred()))
+		Expect(c.GetNacosConfig()).Should(BeNil())
+	})
+})
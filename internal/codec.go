@@ -0,0 +1,365 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	. "config/primitive"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultFormat = "yaml"
+
+var (
+	codecMutex sync.RWMutex
+	codecs     = map[string]Codec{
+		"yaml": yamlCodec{},
+		"yml":  yamlCodec{},
+		"json": jsonCodec{},
+		"toml": tomlCodec{},
+		"env":  dotenvCodec{},
+	}
+)
+
+// RegisterCodec 注册编解码器，format为文件扩展名（不含"."），已存在时覆盖
+func RegisterCodec(format string, codec Codec) {
+	codecMutex.Lock()
+	codecs[strings.ToLower(format)] = codec
+	codecMutex.Unlock()
+}
+
+// LookupCodec 获取已注册格式的编解码器
+func LookupCodec(format string) (Codec, error) {
+	return codecByFormat(format)
+}
+
+// codecByFormat 根据格式获取编解码器
+func codecByFormat(format string) (Codec, error) {
+	codecMutex.RLock()
+	codec, exist := codecs[strings.ToLower(format)]
+	codecMutex.RUnlock()
+
+	if !exist {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	return codec, nil
+}
+
+// codecByFile 根据文件扩展名获取编解码器，没有扩展名时按照yaml处理
+func codecByFile(file string) (Codec, error) {
+	ext := strings.TrimPrefix(filepath.Ext(file), ".")
+	if ext == "" {
+		ext = defaultFormat
+	}
+
+	return codecByFormat(ext)
+}
+
+// hasFormat dataID的扩展名是否为已注册的格式
+func hasFormat(dataID string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(dataID), ".")
+	if ext == "" {
+		return false
+	}
+
+	_, err := codecByFormat(ext)
+	return err == nil
+}
+
+// codecByDataID 获取nacos配置的编解码器
+//  优先使用指定的format，其次为dataID的扩展名；dataID中的"."不一定表示扩展名，无法识别时按照yaml处理
+func codecByDataID(dataID, format string) (Codec, error) {
+	if format != "" {
+		return codecByFormat(format)
+	}
+
+	if codec, err := codecByFile(dataID); err == nil {
+		return codec, nil
+	}
+
+	return codecByFormat(defaultFormat)
+}
+
+// convert 通过yaml将in转换为out，字段统一按照yaml tag匹配
+func convert(in, out interface{}) error {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(data, out)
+}
+
+// stringKeys 将yaml解析出的map[interface{}]interface{}转换为map[string]interface{}
+func stringKeys(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[k] = stringKeys(item)
+		}
+		return m
+
+	case []interface{}:
+		s := make([]interface{}, len(value))
+		for i, item := range value {
+			s[i] = stringKeys(item)
+		}
+		return s
+	}
+
+	return v
+}
+
+// toTree 将对象转换为以map[string]interface{}表示的树
+func toTree(v interface{}) (interface{}, error) {
+	var tree interface{}
+	if err := convert(v, &tree); err != nil {
+		return nil, err
+	}
+
+	return stringKeys(tree), nil
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Marshal(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
+
+// jsonCodec json格式，字段按照yaml tag匹配，与yaml配置共用同一个结构体
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(tree, "", "  ")
+}
+
+// Unmarshal 数字按照json.Number解析，避免超过2^53的整数经float64转换后丢失精度
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+
+	// 与json.Unmarshal一致，不允许之后还有其他内容
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level json value")
+	}
+
+	return convert(jsonNumbers(tree), v)
+}
+
+// jsonNumbers 将json.Number转换为int64、uint64或float64
+func jsonNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = jsonNumbers(item)
+		}
+
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonNumbers(item)
+		}
+
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+
+		if u, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return u
+		}
+
+		f, _ := value.Float64()
+		return f
+	}
+
+	return v
+}
+
+// tomlCodec toml格式，字段按照yaml tag匹配，与yaml配置共用同一个结构体
+type tomlCodec struct{}
+
+func (tomlCodec) Marshal(v interface{}) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = toml.NewEncoder(buf).Encode(tree); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (tomlCodec) Unmarshal(data []byte, v interface{}) error {
+	tree := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+
+	return convert(tree, v)
+}
+
+// envSeparator .env文件中表示层级的分隔符，e.g. REDIS__PORT=6379 对应 redis.port
+const envSeparator = "__"
+
+// dotenvCodec .env格式，key转换为小写后按照yaml tag匹配
+type dotenvCodec struct{}
+
+func (dotenvCodec) Marshal(v interface{}) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, item := range m {
+				key := strings.ToUpper(k)
+				if prefix != "" {
+					key = prefix + envSeparator + key
+				}
+				flatten(key, item)
+			}
+			return
+		}
+
+		value := ""
+		if v != nil {
+			value = fmt.Sprint(v)
+		}
+
+		if strings.ContainsAny(value, " #\"'\\\n\t") {
+			value = strconv.Quote(value)
+		}
+
+		lines = append(lines, prefix+"="+value)
+	}
+
+	flatten("", tree)
+	sort.Strings(lines)
+
+	buf := &bytes.Buffer{}
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (dotenvCodec) Unmarshal(data []byte, v interface{}) error {
+	tree := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return fmt.Errorf("invalid env line %d: %s", n, line)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		value, err := parseEnvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return fmt.Errorf("invalid env line %d: %w", n, err)
+		}
+
+		// 按照分隔符生成嵌套的map
+		node := tree
+		keys := strings.Split(key, envSeparator)
+		for _, k := range keys[:len(keys)-1] {
+			child, ok := node[k].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[k] = child
+			}
+			node = child
+		}
+		node[keys[len(keys)-1]] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return convert(tree, v)
+}
+
+// parseEnvValue 解析.env中的值
+//  带引号的值保持为字符串；不带引号的值去掉行尾注释后，按照yaml规则识别数字、bool等标量类型
+func parseEnvValue(raw string) (interface{}, error) {
+	if len(raw) >= 2 {
+		switch raw[0] {
+		case '"':
+			end := strings.LastIndex(raw, "\"")
+			return strconv.Unquote(raw[:end+1])
+		case '\'':
+			if end := strings.LastIndex(raw, "'"); end > 0 {
+				return raw[1:end], nil
+			}
+		}
+	}
+
+	if idx := strings.Index(raw, " #"); idx >= 0 {
+		raw = strings.TrimSpace(raw[:idx])
+	}
+
+	return parseScalar(raw), nil
+}
+
+// parseScalar 按照yaml规则识别数字、bool等标量类型，其他值保持为字符串
+func parseScalar(raw string) interface{} {
+	var scalar interface{}
+	if err := yaml.Unmarshal([]byte(raw), &scalar); err != nil {
+		return raw
+	}
+
+	switch scalar.(type) {
+	case int, int64, uint64, float64, bool:
+		return scalar
+	}
+
+	return raw
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"strings"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// upperCodec 测试用编解码器，内容为yaml转大写
+type upperCodec struct{ yamlCodec }
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	return yamlCodec{}.Unmarshal([]byte(strings.ToLower(string(data))), v)
+}
+
+// keepCodec 保存format当前注册的编解码器，返回恢复注册表的函数，避免测试修改全局的注册表
+func keepCodec(format string) func() {
+	codecMutex.RLock()
+	prev, exist := codecs[format]
+	codecMutex.RUnlock()
+
+	return func() {
+		codecMutex.Lock()
+		defer codecMutex.Unlock()
+
+		if exist {
+			codecs[format] = prev
+		} else {
+			delete(codecs, format)
+		}
+	}
+}
+
+var _ = Describe("Codec", func() {
+	Context("file format", func() {
+		for _, file := range []string{"mixed.yaml", "mixed.json", "mixed.toml", "mixed.env"} {
+			file := file
+
+			It(file, func() {
+				c := NewConfigIns()
+				err := c.RegisterFile(file, &MongoConf{})
+				Expect(err).Should(Succeed())
+
+				conf := c.GetFileConfig().(*MongoConf)
+				Expect(conf.DB == "monkey").Should(BeTrue())
+				Expect(conf.Host == "mongodb://localhost:27017/monkey").Should(BeTrue())
+				Expect(conf.MaxPoolSize == 10).Should(BeTrue())
+				Expect(conf.Timeout == 10000).Should(BeTrue())
+				Expect(conf.MaxConnIdleTime == 300).Should(BeTrue())
+			})
+		}
+
+		It("unknown format", func() {
+			c := NewConfigIns()
+			err := c.RegisterFile("default.unknown", &MongoConf{})
+			Expect(errors.Is(err, primitive.ErrUnknownFormat)).Should(BeTrue())
+		})
+	})
+
+	Context("marshal", func() {
+		for _, format := range []string{"yaml", "json", "toml", "env"} {
+			format := format
+
+			It(format, func() {
+				codec, err := codecByFormat(format)
+				Expect(err).Should(Succeed())
+
+				data, err := codec.Marshal(&MongoConf{DB: "monkey", Host: "mongodb://localhost:27017/monkey", MaxPoolSize: 10})
+				Expect(err).Should(Succeed())
+
+				conf := &MongoConf{}
+				Expect(codec.Unmarshal(data, conf)).Should(Succeed())
+				Expect(conf.Host == "mongodb://localhost:27017/monkey").Should(BeTrue())
+				Expect(conf.MaxPoolSize == 10).Should(BeTrue())
+			})
+		}
+	})
+
+	Context("json", func() {
+		It("large numbers", func() {
+			type numbers struct {
+				ID    int64   `yaml:"id"`
+				Max   uint64  `yaml:"max"`
+				Ratio float64 `yaml:"ratio"`
+			}
+
+			conf := &numbers{}
+			data := []byte(`{"id": 9007199254740993, "max": 18446744073709551615, "ratio": 1.5}`)
+			Expect(jsonCodec{}.Unmarshal(data, conf)).Should(Succeed())
+			Expect(conf.ID == 9007199254740993).Should(BeTrue())
+			Expect(conf.Max == 18446744073709551615).Should(BeTrue())
+			Expect(conf.Ratio == 1.5).Should(BeTrue())
+
+			Expect(jsonCodec{}.Unmarshal([]byte(`{"id": 1} x`), conf)).ShouldNot(Succeed())
+		})
+	})
+
+	Context("dotenv", func() {
+		It("nested keys & quotes", func() {
+			data := "PORT=9900\nREDIS__HOST='127.0.0.1'\nREDIS__PORT=6379\nREDIS__WAIT=true\nPRODUCT_NAME=\"a # b\"\n"
+
+			conf := &Configure{}
+			Expect(dotenvCodec{}.Unmarshal([]byte(data), conf)).Should(Succeed())
+			Expect(conf.Port == 9900).Should(BeTrue())
+			Expect(conf.ProductName == "a # b").Should(BeTrue())
+			Expect(conf.Redis.Host == "127.0.0.1").Should(BeTrue())
+			Expect(conf.Redis.Port == 6379).Should(BeTrue())
+			Expect(conf.Redis.Wait).Should(BeTrue())
+		})
+
+		It("invalid line", func() {
+			conf := &Configure{}
+			Expect(dotenvCodec{}.Unmarshal([]byte("PORT"), conf)).ShouldNot(Succeed())
+		})
+	})
+
+	Context("register codec", func() {
+		It("custom format", func() {
+			defer keepCodec("upper")()
+			RegisterCodec("upper", upperCodec{})
+
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: "mongo.upper", Group: group, Content: "HOST: MONGODB://SERVER:27017/MONKEY"})
+
+			err := c.RegisterNacosStruct("mongo.upper", group, &MongoConf{})
+			Expect(err).Should(Succeed())
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "mongodb://server:27017/monkey").Should(BeTrue())
+		})
+
+		It("keep codec", func() {
+			restore := keepCodec("yaml")
+			RegisterCodec("yaml", upperCodec{})
+			restore()
+
+			codec, err := LookupCodec("yaml")
+			Expect(err).Should(Succeed())
+			Expect(codec == primitive.Codec(yamlCodec{})).Should(BeTrue())
+
+			keepCodec("upper")()
+			_, err = LookupCodec("upper")
+			Expect(errors.Is(err, primitive.ErrUnknownFormat)).Should(BeTrue())
+		})
+
+		It("nacos format", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: `{"host": "mongodb://server:27017/monkey"}`})
+
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithFormat("json"))
+			Expect(err).Should(Succeed())
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "mongodb://server:27017/monkey").Should(BeTrue())
+		})
+
+		It("unknown nacos format", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: x"})
+
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithFormat("xml"))
+			Expect(errors.Is(err, primitive.ErrUnknownFormat)).Should(BeTrue())
+		})
+	})
+})
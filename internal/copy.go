@@ -0,0 +1,90 @@
+package internal
+
+import (
+	. "config/primitive"
+	"reflect"
+)
+
+// deepCopy 深拷贝对象，指针、slice、map、interface指向的内容都会复制；不支持循环引用
+//  未导出的字段无法通过反射赋值，只做浅拷贝
+func deepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return copyValue(reflect.ValueOf(v)).Interface()
+}
+
+// copyValue 深拷贝rv，返回值与rv的类型相同
+func copyValue(rv reflect.Value) reflect.Value {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv
+		}
+
+		cp := reflect.New(rv.Type().Elem())
+		cp.Elem().Set(copyValue(rv.Elem()))
+		return cp
+
+	case reflect.Struct:
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		for i := 0; i < rv.NumField(); i++ {
+			if cp.Field(i).CanSet() {
+				cp.Field(i).Set(copyValue(rv.Field(i)))
+			}
+		}
+		return cp
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(copyValue(rv.Index(i)))
+		}
+		return cp
+
+	case reflect.Array:
+		cp := reflect.New(rv.Type()).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(copyValue(rv.Index(i)))
+		}
+		return cp
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return cp
+
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(copyValue(rv.Elem()))
+		return cp
+	}
+
+	return rv
+}
+
+// readValue 读取配置时返回的对象，WithDeepCopy时返回深拷贝
+func readValue(v interface{}, options *RegisterOptions) interface{} {
+	if v == nil || !options.DeepCopy {
+		return v
+	}
+
+	return deepCopy(v)
+}
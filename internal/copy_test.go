@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"config/primitive"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Copy", func() {
+	It("deep copy", func() {
+		ratio := 0.5
+		conf := &EnvConfig{
+			LogLevel: "info",
+			Hosts:    []string{"a", "b"},
+			Ratio:    &ratio,
+			Envs:     map[string]*RedisConf{"local": {Host: "localhost"}},
+		}
+
+		cp := deepCopy(conf).(*EnvConfig)
+		Expect(cp).Should(Equal(conf))
+		Expect(cp != conf).Should(BeTrue())
+
+		cp.Hosts[0] = "c"
+		*cp.Ratio = 1
+		cp.Envs["local"].Host = "remote"
+		cp.Envs["prod"] = &RedisConf{}
+
+		Expect(conf.Hosts[0] == "a").Should(BeTrue())
+		Expect(*conf.Ratio == 0.5).Should(BeTrue())
+		Expect(conf.Envs["local"].Host == "localhost").Should(BeTrue())
+		Expect(len(conf.Envs) == 1).Should(BeTrue())
+
+		Expect(deepCopy(nil)).Should(BeNil())
+	})
+
+	It("with deep copy", func() {
+		c := NewConfigIns()
+		Expect(c.RegisterFileWithName("shared", "mixed.yaml", &MongoConf{})).Should(Succeed())
+		Expect(c.RegisterFileWithName("copied", "mixed.yaml", &MongoConf{}, primitive.WithDeepCopy())).Should(Succeed())
+
+		Expect(c.GetFileConfigByName("shared") == c.GetFileConfigByName("shared")).Should(BeTrue())
+
+		conf := c.GetFileConfigByName("copied").(*MongoConf)
+		Expect(conf != c.GetFileConfigByName("copied")).Should(BeTrue())
+
+		conf.Host = "changed"
+		Expect(c.GetFileConfigByName("copied").(*MongoConf).Host == "mongodb://localhost:27017/monkey").Should(BeTrue())
+	})
+
+	It("mixed copy on write", func() {
+		c, client := newMixedIns()
+		Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+
+		var changes []primitive.Change
+		c.Subscribe(defaultName, func(old, new interface{}) {
+			changes = append(changes, primitive.Change{Old: old, New: new})
+		})
+
+		before := c.GetMixedConfig().(*MongoConf)
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://other:27017/monkey"})
+		after := c.GetMixedConfig().(*MongoConf)
+
+		Expect(before != after).Should(BeTrue())
+		Expect(before.Host == "mongodb://server:27017/monkey").Should(BeTrue())
+		Expect(after.Host == "mongodb://other:27017/monkey").Should(BeTrue())
+
+		Expect(len(changes) == 1).Should(BeTrue())
+		Expect(changes[0].Old == before && changes[0].New == after).Should(BeTrue())
+	})
+})
@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// applyEnv 使用环境变量覆盖配置对象中的字段
+//  字段的env tag为环境变量名称，"-"表示忽略；prefix不为空时，没有env tag的字段按照 PREFIX_父级_字段 的规则
+//  通过yaml tag生成环境变量名称，e.g. prefix为APP时，redis.port对应APP_REDIS_PORT
+func applyEnv(v interface{}, prefix string) error {
+	return walkEnv(reflect.ValueOf(v), strings.ToUpper(prefix))
+}
+
+// envName 根据父级名称和yaml key生成环境变量名称
+func envName(parent, key string) string {
+	key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	if parent == "" {
+		return key
+	}
+
+	return parent + "_" + key
+}
+
+// yamlKey 获取字段在yaml中的key，与yaml.v2的规则一致；返回inline表示字段内联
+func yamlKey(field reflect.StructField) (key string, inline bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, flag := range parts[1:] {
+		if flag == "inline" {
+			return "", true
+		}
+	}
+
+	if parts[0] != "" {
+		return parts[0], false
+	}
+
+	return strings.ToLower(field.Name), false
+}
+
+// walkEnv 遍历对象，name为当前对象对应的环境变量名称前缀，为空时只处理env tag
+func walkEnv(rv reflect.Value, name string) error {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return walkEnv(rv.Elem(), name)
+
+	case reflect.Struct:
+		if isScalar(rv.Type()) || !rv.CanSet() {
+			return nil
+		}
+
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			key, inline := yamlKey(field)
+			if key == "" && !inline {
+				continue
+			}
+
+			child := ""
+			switch {
+			case inline:
+				child = name
+			case name != "":
+				child = envName(name, key)
+			}
+
+			if err := applyField(rv.Field(i), field, child); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		for _, k := range rv.MapKeys() {
+			child := ""
+			if name != "" {
+				child = envName(name, k.String())
+			}
+
+			item := rv.MapIndex(k)
+			if item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+				if err := walkEnv(item, child); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if item.Kind() != reflect.Struct {
+				continue
+			}
+
+			// map中的struct不可寻址，复制后再写回
+			cp := reflect.New(item.Type()).Elem()
+			cp.Set(item)
+			if err := walkEnv(cp, child); err != nil {
+				return err
+			}
+			rv.SetMapIndex(k, cp)
+		}
+
+	case reflect.Interface:
+		if !rv.IsNil() {
+			return walkEnv(rv.Elem(), name)
+		}
+	}
+
+	return nil
+}
+
+// applyField 处理单个字段，env tag优先于生成的名称
+func applyField(fv reflect.Value, field reflect.StructField, name string) error {
+	if tag, ok := field.Tag.Lookup("env"); ok {
+		if tag == "-" {
+			return nil
+		}
+		name = tag
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !isScalar(t) && !(t.Kind() == reflect.Slice && isScalar(t.Elem())) {
+		return walkEnv(fv, name)
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	value, exist := os.LookupEnv(name)
+	if !exist {
+		return nil
+	}
+
+	if err := setValue(fv, value); err != nil {
+		return fmt.Errorf("env %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// isScalar 是否为可以直接由字符串转换的类型
+func isScalar(t reflect.Type) bool {
+	if t == durationType || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// setValue 将字符串转换为字段的类型并赋值，slice按照","分割
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	if reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if fv.Type() == durationType {
+		d, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+
+	case reflect.Slice:
+		items := []string{}
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
+
+// parseDuration 解析时长，支持"10s"格式；纯数字时与yaml解析结果保持一致，直接作为time.Duration的值
+func parseDuration(value string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(n), nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"config/primitive"
+	"os"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type EnvConfig struct {
+	LogLevel string        `yaml:"log_level" env:"CONFIG_TEST_LOG_LEVEL"`
+	Secret   string        `yaml:"secret" env:"-"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Hosts    []string      `yaml:"hosts"`
+	Ratio    *float64      `yaml:"ratio"`
+	Redis    RedisConf     `yaml:"redis"`
+
+	Envs map[string]*RedisConf `yaml:"envs"`
+}
+
+var _ = Describe("Env", func() {
+	setEnv := func(kv map[string]string) func() {
+		for k, v := range kv {
+			os.Setenv(k, v)
+		}
+
+		return func() {
+			for k := range kv {
+				os.Unsetenv(k)
+			}
+		}
+	}
+
+	It("tags & prefix", func() {
+		defer setEnv(map[string]string{
+			"CONFIG_TEST_LOG_LEVEL":   "info",
+			"APP_SECRET":              "leaked",
+			"APP_TIMEOUT":             "3s",
+			"APP_HOSTS":               "a, b",
+			"APP_RATIO":               "0.5",
+			"APP_REDIS_PORT":          "6380",
+			"APP_REDIS_WAIT":          "true",
+			"APP_REDIS_READ_TIMEOUT":  "10000",
+			"APP_ENVS_LOCAL_HOST":     "10.0.0.1",
+			"APP_ENVS_LOCAL_MAX_IDLE": "8",
+		})()
+
+		conf := &EnvConfig{Secret: "secret", Envs: map[string]*RedisConf{"local": {}}}
+		Expect(applyEnv(conf, "app")).Should(Succeed())
+
+		Expect(conf.LogLevel == "info").Should(BeTrue())
+		Expect(conf.Secret == "secret").Should(BeTrue())
+		Expect(conf.Timeout == 3*time.Second).Should(BeTrue())
+		Expect(conf.Hosts).Should(Equal([]string{"a", "b"}))
+		Expect(*conf.Ratio == 0.5).Should(BeTrue())
+		Expect(conf.Redis.Port == 6380).Should(BeTrue())
+		Expect(conf.Redis.Wait).Should(BeTrue())
+		Expect(conf.Redis.ReadTimeout == 10000).Should(BeTrue())
+		Expect(conf.Envs["local"].Host == "10.0.0.1").Should(BeTrue())
+		Expect(conf.Envs["local"].MaxIdle == 8).Should(BeTrue())
+	})
+
+	It("no prefix only env tag", func() {
+		defer setEnv(map[string]string{"CONFIG_TEST_LOG_LEVEL": "info", "TIMEOUT": "3s"})()
+
+		conf := &EnvConfig{}
+		Expect(applyEnv(conf, "")).Should(Succeed())
+		Expect(conf.LogLevel == "info").Should(BeTrue())
+		Expect(conf.Timeout == 0).Should(BeTrue())
+	})
+
+	It("invalid value", func() {
+		defer setEnv(map[string]string{"APP_REDIS_PORT": "port"})()
+
+		conf := &EnvConfig{}
+		err := applyEnv(conf, "APP")
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("APP_REDIS_PORT"))
+	})
+
+	It("file mode", func() {
+		defer setEnv(map[string]string{"APP_REDIS_HOST": "redis.local"})()
+
+		c := NewConfigIns()
+		err := c.RegisterFile("default.yaml", &Configure{}, primitive.WithProfile(""), primitive.WithEnv("APP"))
+		Expect(err).Should(Succeed())
+		Expect(c.GetFileConfig().(*Configure).Redis.Host == "redis.local").Should(BeTrue())
+	})
+
+	It("nacos & mixed mode", func() {
+		defer setEnv(map[string]string{"APP_HOST": "mongodb://env:27017/monkey"})()
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://server:27017/monkey"})
+
+		err := c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithEnv("APP"))
+		Expect(err).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "mongodb://env:27017/monkey").Should(BeTrue())
+
+		err = c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{}, primitive.WithEnv("APP"), primitive.WithSharedListener())
+		Expect(err).Should(Succeed())
+		Expect(c.GetMixedConfig().(*MongoConf).Host == "mongodb://env:27017/monkey").Should(BeTrue())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://other:27017/monkey"})
+		Expect(c.GetMixedConfig().(*MongoConf).Host == "mongodb://env:27017/monkey").Should(BeTrue())
+	})
+})
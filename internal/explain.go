@@ -0,0 +1,322 @@
+package internal
+
+import (
+	. "config/primitive"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Explain 获取name对应配置中path的生效值及其来源，path为空时为整个配置
+//  文件、nacos模式的来源为配置内容；混合模式按照WithMerge及字段的merge tag决定nacos内容是否覆盖配置文件；分层模式按照层的优先级；
+//  WithEnv设置的环境变量优先级最高。来源都不包含path时，值来自default tag或零值
+func (c *configIns) Explain(name, path string) (Explanation, error) {
+	value, err := c.lookup(name, path)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	var keys []string
+	if path != "" {
+		keys = strings.Split(path, ".")
+	}
+
+	s := c.load()
+	_, flag := c.GetConfigWithFlagByName(name)
+
+	// 按照优先级从低到高排列
+	var (
+		origins []Origin
+		options *RegisterOptions
+		proto   interface{}
+	)
+
+	add := func(o Origin, exist bool) {
+		if exist {
+			origins = append(origins, o)
+		}
+	}
+
+	switch flag {
+	case Mixed:
+		mc, exist := s.mixed[name]
+		if !exist {
+			return Explanation{}, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+		}
+
+		options, proto = mc.options, mc.get()
+		fileOrigin, inFile := contentOrigin(Origin{Source: OnlyFile, File: mc.file}, mc.codec, mc.data, options, keys)
+
+		var (
+			nacosOrigin Origin
+			inNacos     bool
+		)
+
+		if content := mc.nacosContent(); content != "" {
+			codec, err := codecByDataID(mc.dataID, options.Format)
+			if err != nil {
+				return Explanation{}, err
+			}
+
+			nacosOrigin, inNacos = contentOrigin(Origin{Source: OnlyNacos, DataID: mc.dataID, Group: mc.group}, codec, []byte(content), options, keys)
+
+			// 按照合并方式去掉未生效的来源：replace时不使用配置文件；
+			//  MergeField时ignore的字段不使用nacos，replace的字段整体使用nacos
+			switch options.Merge {
+			case MergeReplace:
+				inFile = false
+			case MergeField:
+				mode, depth := mergeMode(reflect.TypeOf(proto), keys)
+				switch mode {
+				case "ignore":
+					inNacos = false
+				case "replace":
+					if _, exist := contentOrigin(Origin{}, codec, []byte(content), options, keys[:depth+1]); exist {
+						inFile = false
+					}
+				}
+			}
+		}
+
+		add(fileOrigin, inFile)
+		add(nacosOrigin, inNacos)
+
+	case OnlyFile:
+		fc, exist := s.files[name]
+		if !exist {
+			return Explanation{}, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+		}
+
+		options, proto = fc.options, fc.proto
+		add(contentOrigin(Origin{Source: OnlyFile, File: fc.file}, fc.codec, fc.data(), options, keys))
+
+	case OnlyNacos:
+		nc, exist := s.nacos[name]
+		if !exist {
+			return Explanation{}, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+		}
+
+		options, proto = nc.options, nc.proto
+		if content := nc.content(); content != "" {
+			add(contentOrigin(Origin{Source: OnlyNacos, DataID: nc.dataID, Group: nc.group}, nc.codec, []byte(content), options, keys))
+		}
+
+	case Layered:
+		lc, exist := s.layered[name]
+		if !exist {
+			return Explanation{}, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+		}
+
+		options, proto = lc.options, lc.proto
+		origins = lc.origins(keys)
+	}
+
+	if proto != nil {
+		add(envOrigin(proto, options, strings.Join(keys, ".")))
+	}
+
+	e := Explanation{Name: name, Path: path, Flag: flag, Value: value}
+	if len(origins) == 0 {
+		e.Origin = Origin{Source: Defaults, Value: value}
+		return e, nil
+	}
+
+	e.Origin = origins[len(origins)-1]
+	for i := len(origins) - 2; i >= 0; i-- {
+		e.Overridden = append(e.Overridden, origins[i])
+	}
+
+	return e, nil
+}
+
+// mergeMode path经过的字段中第一个merge tag为ignore或replace的字段，返回tag及字段在path中的下标
+func mergeMode(t reflect.Type, path []string) (string, int) {
+	for i, key := range path {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch {
+		case t == nil:
+			return "", -1
+		case t.Kind() == reflect.Struct:
+			field, ok := structField(t, key)
+			if !ok {
+				return "", -1
+			}
+
+			if mode := field.Tag.Get("merge"); mode == "ignore" || mode == "replace" {
+				return mode, i
+			}
+
+			t = field.Type
+		case t.Kind() == reflect.Map:
+			t = t.Elem()
+		default:
+			return "", -1
+		}
+	}
+
+	return "", -1
+}
+
+// origins 包含path的层，按照优先级从低到高排列
+func (lc *layeredConfig) origins(path []string) []Origin {
+	var origins []Origin
+	for i, ld := range lc.load().data {
+		layer := lc.layers[i]
+		o := Origin{Source: layer.Source, Layer: layer.Name}
+
+		switch layer.Source {
+		case OnlyFile, OnlyNacos:
+			if ld == nil || ld.raw == nil {
+				continue
+			}
+
+			o.File, o.DataID, o.Group = layer.File, layer.DataID, layer.Group
+			if o, exist := contentOrigin(o, ld.codec, ld.raw, lc.options, path); exist {
+				origins = append(origins, o)
+			}
+
+		default:
+			if ld == nil || ld.tree == nil {
+				continue
+			}
+
+			value, exist := walk(ld.tree, path)
+			if !exist {
+				continue
+			}
+
+			o.Key, o.Value = ld.keys[strings.Join(path, ".")], value
+			origins = append(origins, o)
+		}
+	}
+
+	return origins
+}
+
+// contentOrigin 在文件、nacos内容中查找path，WithProfile时在当前环境的配置中查找
+func contentOrigin(o Origin, codec Codec, raw []byte, options *RegisterOptions, path []string) (Origin, bool) {
+	var tree interface{}
+	if err := codec.Unmarshal(raw, &tree); err != nil {
+		return o, false
+	}
+
+	tree = stringKeys(tree)
+	if options.UseProfile {
+		m, _ := tree.(map[string]interface{})
+		path = append([]string{envsKey, resolveProfile(m, options)}, path...)
+	}
+
+	value, exist := walk(tree, path)
+	if !exist {
+		return o, false
+	}
+
+	switch codec.(type) {
+	case yamlCodec, jsonCodec:
+		o.Line = lineOf(raw, path)
+	}
+
+	o.Value = value
+	o.Version = checksum(string(raw))
+	return o, true
+}
+
+// envOrigin WithEnv时path对应的已设置的环境变量
+func envOrigin(proto interface{}, options *RegisterOptions, path string) (Origin, bool) {
+	if !options.Env {
+		return Origin{}, false
+	}
+
+	leaf, exist := envLeaves(reflect.TypeOf(proto), options.EnvPrefix)[path]
+	if !exist {
+		return Origin{}, false
+	}
+
+	value, exist := os.LookupEnv(leaf.name)
+	if !exist {
+		return Origin{}, false
+	}
+
+	return Origin{Source: EnvVars, Key: leaf.name, Value: leafValue(leaf.t, value)}, true
+}
+
+// lineOf 获取yaml（json）内容中path所在的行号，无法解析或定位时返回0
+func lineOf(raw []byte, path []string) int {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+
+	node := doc.Content[0]
+	line := node.Line
+	for _, key := range path {
+		for node.Kind == yamlv3.AliasNode {
+			node = node.Alias
+		}
+
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			k, v := mappingValue(node, key)
+			if k == nil {
+				return 0
+			}
+
+			line, node = k.Line, v
+
+		case yamlv3.SequenceNode:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				return 0
+			}
+
+			node = node.Content[idx]
+			line = node.Line
+
+		default:
+			return 0
+		}
+	}
+
+	return line
+}
+
+// mappingValue 获取mapping中key对应的节点，包括通过"<<"合并的key
+func mappingValue(node *yamlv3.Node, key string) (k, v *yamlv3.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "<<" {
+			continue
+		}
+
+		merged := []*yamlv3.Node{node.Content[i+1]}
+		if merged[0].Kind == yamlv3.SequenceNode {
+			merged = merged[0].Content
+		}
+
+		for _, m := range merged {
+			for m.Kind == yamlv3.AliasNode {
+				m = m.Alias
+			}
+
+			if m.Kind == yamlv3.MappingNode {
+				if k, v = mappingValue(m, key); k != nil {
+					return k, v
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Explain", func() {
+	It("file & profile", func() {
+		c := NewConfigIns()
+		Expect(c.RegisterFile("app.yaml", &RedisConf{}, primitive.WithProfile("local"))).Should(Succeed())
+
+		e, err := c.Explain(defaultName, "port")
+		Expect(err).Should(Succeed())
+		Expect(e.Flag == primitive.OnlyFile).Should(BeTrue())
+		Expect(e.Value == 9900).Should(BeTrue())
+		Expect(e.Origin.Source == primitive.OnlyFile).Should(BeTrue())
+		Expect(e.Origin.File == "app.yaml").Should(BeTrue())
+		Expect(e.Origin.Line == 5).Should(BeTrue()) // 通过<<合并的default
+		Expect(e.Origin.Version != "").Should(BeTrue())
+		Expect(e.Overridden).Should(BeEmpty())
+
+		// 配置中不存在的字段
+		e, err = c.Explain(defaultName, "max_active")
+		Expect(err).Should(Succeed())
+		Expect(e.Origin.Source == primitive.Defaults).Should(BeTrue())
+
+		_, err = c.Explain(defaultName, "none")
+		Expect(errors.Is(err, primitive.ErrPathNotFound)).Should(BeTrue())
+
+		_, err = c.Explain("none", "port")
+		Expect(errors.Is(err, primitive.ErrNotRegistered)).Should(BeTrue())
+	})
+
+	It("env override", func() {
+		defer os.Unsetenv("EXPLAIN_HOST")
+		os.Setenv("EXPLAIN_HOST", "10.0.0.1")
+
+		c := NewConfigIns()
+		Expect(c.RegisterFile("mixed.yaml", &MongoConf{}, primitive.WithEnv("explain"))).Should(Succeed())
+
+		e, err := c.Explain(defaultName, "host")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == "10.0.0.1").Should(BeTrue())
+		Expect(e.Origin.Source == primitive.EnvVars).Should(BeTrue())
+		Expect(e.Origin.Key == "EXPLAIN_HOST").Should(BeTrue())
+		Expect(len(e.Overridden) == 1).Should(BeTrue())
+		Expect(e.Overridden[0].Line == 4).Should(BeTrue())
+		Expect(e.Overridden[0].Value == "mongodb://localhost:27017/monkey").Should(BeTrue())
+	})
+
+	It("nacos", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "{\n  \"host\": \"a\",\n  \"db\": \"b\"\n}"})
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithFormat("json"))).Should(Succeed())
+
+		e, err := c.Explain(defaultName, "db")
+		Expect(err).Should(Succeed())
+		Expect(e.Origin.Source == primitive.OnlyNacos).Should(BeTrue())
+		Expect(e.Origin.DataID == dataID && e.Origin.Group == group).Should(BeTrue())
+		Expect(e.Origin.Line == 3).Should(BeTrue())
+		Expect(e.Origin.Version == checksum("{\n  \"host\": \"a\",\n  \"db\": \"b\"\n}")).Should(BeTrue())
+	})
+
+	It("mixed", func() {
+		c, _ := newMixedIns()
+		Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+
+		e, err := c.Explain(defaultName, "host")
+		Expect(err).Should(Succeed())
+		Expect(e.Flag == primitive.Mixed).Should(BeTrue())
+		Expect(e.Origin.Source == primitive.OnlyNacos).Should(BeTrue())
+		Expect(e.Origin.Line == 1).Should(BeTrue())
+		Expect(len(e.Overridden) == 1).Should(BeTrue())
+		Expect(e.Overridden[0].Source == primitive.OnlyFile).Should(BeTrue())
+		Expect(e.Overridden[0].File == "mixed.yaml").Should(BeTrue())
+	})
+
+	It("mixed merge", func() {
+		dir, err := ioutil.TempDir("", "explain")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "app.yaml")
+		Expect(ioutil.WriteFile(file, []byte(mergeFile), 0644)).Should(Succeed())
+
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mergeContent})
+		Expect(c.RegisterMixedWithName("field", file, dataID, group, &MergeConf{}, primitive.WithMerge(primitive.MergeField))).Should(Succeed())
+		Expect(c.RegisterMixedWithName("replace", file, dataID, group, &MergeConf{},
+			primitive.WithMerge(primitive.MergeReplace), primitive.WithSharedListener())).Should(Succeed())
+
+		// ignore的字段不使用nacos的内容
+		e, err := c.Explain("field", "secret")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == "file" && e.Origin.Source == primitive.OnlyFile).Should(BeTrue())
+		Expect(e.Overridden).Should(BeEmpty())
+
+		// replace的字段整体使用nacos的内容
+		e, err = c.Explain("field", "tags.x")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == "nacos" && e.Origin.Source == primitive.OnlyNacos).Should(BeTrue())
+		Expect(e.Overridden).Should(BeEmpty())
+
+		e, err = c.Explain("field", "labels.b")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == "file" && e.Origin.Source == primitive.OnlyFile).Should(BeTrue())
+
+		// replace时不使用配置文件的内容
+		e, err = c.Explain("replace", "redis.port")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == 0 && e.Origin.Source == primitive.Defaults).Should(BeTrue())
+
+		e, err = c.Explain("replace", "name")
+		Expect(err).Should(Succeed())
+		Expect(e.Value == "nacos" && e.Origin.Source == primitive.OnlyNacos).Should(BeTrue())
+		Expect(e.Overridden).Should(BeEmpty())
+	})
+
+	It("layered", func() {
+		dir, err := ioutil.TempDir("", "explain")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		base := filepath.Join(dir, "app.yaml")
+		Expect(ioutil.WriteFile(base, []byte("name: app\nredis:\n  host: base"), 0644)).Should(Succeed())
+
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "redis:\n  host: nacos"})
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{
+			primitive.DefaultsLayer(LayeredConf{Redis: RedisConf{Host: "defaults"}}),
+			primitive.FileLayer(base),
+			primitive.NacosLayer(dataID, group),
+		})).Should(Succeed())
+
+		e, err := c.Explain(defaultName, "redis.host")
+		Expect(err).Should(Succeed())
+		Expect(e.Flag == primitive.Layered).Should(BeTrue())
+		Expect(e.Value == "nacos").Should(BeTrue())
+		Expect(e.Origin.Layer == "nacos:"+group+"/"+dataID).Should(BeTrue())
+		Expect(e.Origin.Line == 2).Should(BeTrue())
+
+		Expect(len(e.Overridden) == 2).Should(BeTrue())
+		Expect(e.Overridden[0].Source == primitive.OnlyFile).Should(BeTrue())
+		Expect(e.Overridden[0].Line == 3).Should(BeTrue())
+		Expect(e.Overridden[0].Value == "base").Should(BeTrue())
+		Expect(e.Overridden[1].Source == primitive.Defaults).Should(BeTrue())
+		Expect(e.Overridden[1].Value == "defaults").Should(BeTrue())
+	})
+})
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	. "config/primitive"
+	"sort"
+	"sync"
+	"time"
+)
+
+// failureState 热更新失败的记录
+type failureState struct {
+	mutex   sync.Mutex
+	records map[failureKey]*UpdateFailure
+}
+
+type failureKey struct {
+	name   string
+	source Flag
+}
+
+// failUpdate 热更新失败时调用：累计失败次数，记录导致失败的内容及原因，并通过OnError通知
+//  调用方需保留上一次有效的配置
+func (c *configIns) failUpdate(name string, source Flag, payload string, err error) {
+	c.failures.mutex.Lock()
+	if c.failures.records == nil {
+		c.failures.records = make(map[failureKey]*UpdateFailure)
+	}
+
+	key := failureKey{name: name, source: source}
+	r, exist := c.failures.records[key]
+	if !exist {
+		r = &UpdateFailure{Name: name, Source: source}
+		c.failures.records[key] = r
+	}
+
+	r.Failures++
+	r.Err, r.Payload, r.At = err, payload, time.Now()
+	c.failures.mutex.Unlock()
+
+	c.reportError(name, source, err)
+}
+
+// Failures 热更新失败的记录，按照名称、模式排序；注销配置后清除对应的记录
+func (c *configIns) Failures() []UpdateFailure {
+	c.failures.mutex.Lock()
+	defer c.failures.mutex.Unlock()
+
+	failures := make([]UpdateFailure, 0, len(c.failures.records))
+	for _, r := range c.failures.records {
+		failures = append(failures, *r)
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Name != failures[j].Name {
+			return failures[i].Name < failures[j].Name
+		}
+
+		return failures[i].Source < failures[j].Source
+	})
+
+	return failures
+}
+
+// clearFailures 注销配置时清除其失败记录
+func (c *configIns) clearFailures(name string, sources ...Flag) {
+	c.failures.mutex.Lock()
+	defer c.failures.mutex.Unlock()
+
+	for _, source := range sources {
+		delete(c.failures.records, failureKey{name: name, source: source})
+	}
+}
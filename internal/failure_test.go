@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Failure", func() {
+	It("mixed", func() {
+		c, client := newMixedIns()
+		Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+
+		var reported []primitive.Flag
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			Expect(name == defaultName).Should(BeTrue())
+			reported = append(reported, source)
+		})
+
+		before := c.GetMixedConfig()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: ["})
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: {"})
+
+		// 保留上一次有效的配置
+		Expect(c.GetMixedConfig() == before).Should(BeTrue())
+		Expect(reported).Should(Equal([]primitive.Flag{primitive.Mixed, primitive.Mixed}))
+
+		failures := c.Failures()
+		Expect(len(failures) == 1).Should(BeTrue())
+		Expect(failures[0].Name == defaultName && failures[0].Source == primitive.Mixed).Should(BeTrue())
+		Expect(failures[0].Failures == 2).Should(BeTrue())
+		Expect(failures[0].Payload == "host: {").Should(BeTrue())
+		Expect(failures[0].Err).Should(HaveOccurred())
+		Expect(failures[0].At.IsZero()).Should(BeFalse())
+
+		// 恢复后累计次数不变
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mixedContent})
+		Expect(c.GetMixedConfig() != before).Should(BeTrue())
+		Expect(c.Failures()[0].Failures == 2).Should(BeTrue())
+
+		Expect(c.Unregister(defaultName)).Should(Succeed())
+		Expect(c.Failures()).Should(BeEmpty())
+	})
+
+	It("nacos", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mixedContent})
+		Expect(c.RegisterNacosStructWithName("struct", dataID, group, &MongoConf{})).Should(Succeed())
+
+		client.PublishConfig(vo.ConfigParam{DataId: "app.json", Group: group, Content: `{"host": "a"}`})
+		Expect(c.RegisterNacosWithName("raw", "app.json", group)).Should(Succeed())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "- a"})
+		client.PublishConfig(vo.ConfigParam{DataId: "app.json", Group: group, Content: `{"host": `})
+
+		// 原始内容按照dataID的扩展名校验，非法时保留原内容
+		Expect(c.GetNacosConfigByName("raw") == `{"host": "a"}`).Should(BeTrue())
+		Expect(c.GetNacosConfigByName("struct").(*MongoConf).MaxPoolSize == 150).Should(BeTrue())
+
+		failures := c.Failures()
+		Expect(len(failures) == 2).Should(BeTrue())
+		Expect(failures[0].Name == "raw" && failures[0].Payload == `{"host": `).Should(BeTrue())
+		Expect(failures[1].Name == "struct" && failures[1].Payload == "- a").Should(BeTrue())
+		Expect(failures[1].Source == primitive.OnlyNacos && failures[1].Failures == 1).Should(BeTrue())
+
+		Expect(c.UnregisterNacos(dataID, group)).Should(Succeed())
+		Expect(len(c.Failures()) == 1).Should(BeTrue())
+	})
+
+	It("layered", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "name: nacos"})
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{primitive.NacosLayer(dataID, group)})).Should(Succeed())
+
+		var reported error
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			reported = err
+		})
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "version: v2"})
+		Expect(errors.Is(reported, primitive.ErrInvalidConfig)).Should(BeTrue())
+		Expect(c.GetLayeredConfig().(*LayeredConf).Name == "nacos").Should(BeTrue())
+
+		failures := c.Failures()
+		Expect(len(failures) == 1 && failures[0].Source == primitive.Layered).Should(BeTrue())
+		Expect(failures[0].Payload == "version: v2").Should(BeTrue())
+	})
+})
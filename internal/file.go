@@ -0,0 +1,203 @@
+package internal
+
+import (
+	. "config/primitive"
+	"context"
+	"crypto/md5"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type fileConfig struct {
+	file    string
+	proto   interface{}      // 注册时传入的对象，用于copy出新的配置对象
+	codec   Codec            // 根据文件扩展名选择的编解码器
+	options *RegisterOptions // 注册选项
+	state   atomic.Value     // *fileState，当前生效的配置
+	sum     [md5.Size]byte   // 当前生效的文件内容摘要，用于过滤内容未变化的事件
+
+	fw *fileWatch // WithWatch时的文件监听
+}
+
+// newFileConfig 读取配置文件，生成文件模式的配置
+func newFileConfig(file string, v interface{}, options *RegisterOptions) (*fileConfig, error) {
+	codec, err := codecByFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &fileConfig{file: file, proto: v, codec: codec, options: options}
+	if _, _, err = fc.load(); err != nil {
+		return nil, err
+	}
+
+	return fc, nil
+}
+
+type fileState struct {
+	value interface{} // 配置内容解析后的对象
+	data  []byte      // 文件内容
+}
+
+// get 获取当前生效的配置
+func (fc *fileConfig) get() interface{} {
+	if state, _ := fc.state.Load().(*fileState); state != nil {
+		return state.value
+	}
+
+	return nil
+}
+
+// data 获取当前生效的文件内容
+func (fc *fileConfig) data() []byte {
+	if state, _ := fc.state.Load().(*fileState); state != nil {
+		return state.data
+	}
+
+	return nil
+}
+
+// load 读取并解析配置文件，解析成功后替换当前配置；解析失败时保留原配置，并返回读取到的文件内容
+func (fc *fileConfig) load() (bool, []byte, error) {
+	data, err := ioutil.ReadFile(fc.file)
+	if err != nil {
+		return false, nil, err
+	}
+
+	sum := md5.Sum(data)
+	if fc.get() != nil && sum == fc.sum {
+		return false, data, nil
+	}
+
+	// copy出一个新的空对象，由于存储配置信息
+	conf, err := decodeConfig(fc.codec, data, fc.proto, fc.options)
+	if err != nil {
+		return false, data, err
+	}
+
+	fc.sum = sum
+	fc.state.Store(&fileState{value: conf, data: data})
+	return true, data, nil
+}
+
+// watch 监听配置文件变化，onChange在配置更新成功后调用，onError在读取或解析失败时调用，payload为解析失败的文件内容
+func (fc *fileConfig) watch(onChange func(old, new interface{}), onError func(payload string, err error)) error {
+	fw, err := watchFile(fc.file, func() {
+		old := fc.get()
+		changed, data, err := fc.load()
+		if err != nil {
+			onError(string(data), err)
+		} else if changed {
+			onChange(old, fc.get())
+		}
+	}, func(err error) {
+		onError("", err)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fc.fw = fw
+	return nil
+}
+
+// close 停止监听配置文件，等待监听协程退出
+func (fc *fileConfig) close(ctx context.Context) error {
+	if fc.fw == nil {
+		return nil
+	}
+
+	return fc.fw.close(ctx)
+}
+
+// fileWatch 单个文件的监听
+type fileWatch struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{} // 监听协程退出后关闭
+}
+
+// watchDelay 文件事件的合并时间，最后一次事件之后没有新的事件时才调用onEvent
+var watchDelay = 100 * time.Millisecond
+
+// watchFile 监听文件变化，文件被写入或替换时调用onEvent
+//  k8s挂载ConfigMap时，配置文件是指向..data目录的symlink，kubelet更新时替换的是..data，
+//  文件本身不会产生事件，因此监听文件所在目录，并通过比对文件的真实路径判断是否发生了替换；
+//  一次写入可能产生多个事件（e.g. 先清空再写入），合并watchDelay内的事件，避免读取到写入一半的内容
+func watchFile(file string, onEvent func(), onError func(err error)) (*fileWatch, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	fw := &fileWatch{watcher: watcher, done: make(chan struct{})}
+	file = filepath.Clean(file)
+	realPath, _ := filepath.EvalSymlinks(file)
+
+	go func() {
+		defer close(fw.done)
+
+		timer := time.NewTimer(watchDelay)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				curPath, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				swapped := curPath != "" && curPath != realPath
+				if !written && !swapped {
+					continue
+				}
+
+				realPath = curPath
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(watchDelay)
+
+			case <-timer.C:
+				onEvent()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				onError(err)
+			}
+		}
+	}()
+
+	return fw, nil
+}
+
+// close 停止监听，等待监听协程退出
+func (fw *fileWatch) close(ctx context.Context) error {
+	err := fw.watcher.Close()
+
+	select {
+	case <-fw.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "config")
+		Expect(err).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(file, content string) {
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).Should(Succeed())
+	}
+
+	getHost := func(c *configIns) string {
+		return c.GetFileConfig().(*MongoConf).Host
+	}
+
+	Context("watch", func() {
+		It("reload after write", func() {
+			file := filepath.Join(dir, "mongo.yaml")
+			writeFile(file, "host: mongodb://localhost:27017/monkey")
+
+			c := NewConfigIns()
+			err := c.RegisterFile(file, &MongoConf{}, primitive.WithWatch())
+			Expect(err).Should(Succeed())
+
+			old := c.GetFileConfig()
+			Expect(getHost(c) == "mongodb://localhost:27017/monkey").Should(BeTrue())
+
+			writeFile(file, "host: mongodb://server:27017/monkey")
+			Eventually(func() string { return getHost(c) }).Should(Equal("mongodb://server:27017/monkey"))
+
+			// 旧对象不会被修改
+			Expect(old.(*MongoConf).Host == "mongodb://localhost:27017/monkey").Should(BeTrue())
+		})
+
+		It("keep last good config", func() {
+			file := filepath.Join(dir, "mongo.yaml")
+			writeFile(file, "host: mongodb://localhost:27017/monkey")
+
+			var mutex sync.Mutex
+			var errs []error
+
+			c := NewConfigIns()
+			c.OnError(func(name string, source primitive.Flag, err error) {
+				Expect(name == defaultName).Should(BeTrue())
+				Expect(source == primitive.OnlyFile).Should(BeTrue())
+
+				mutex.Lock()
+				errs = append(errs, err)
+				mutex.Unlock()
+			})
+
+			err := c.RegisterFile(file, &MongoConf{}, primitive.WithWatch())
+			Expect(err).Should(Succeed())
+
+			writeFile(file, "host: [")
+			Eventually(func() int {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return len(errs)
+			}).ShouldNot(BeZero())
+
+			Expect(getHost(c) == "mongodb://localhost:27017/monkey").Should(BeTrue())
+		})
+
+		It("partial write", func() {
+			file := filepath.Join(dir, "mongo.yaml")
+			writeFile(file, "host: mongodb://localhost:27017/monkey")
+
+			var errs int32
+			c := NewConfigIns()
+			c.OnError(func(name string, source primitive.Flag, err error) {
+				atomic.AddInt32(&errs, 1)
+			})
+
+			err := c.RegisterFile(file, &MongoConf{}, primitive.WithWatch())
+			Expect(err).Should(Succeed())
+
+			// 先清空再写入，不会读取到空文件
+			f, err := os.OpenFile(file, os.O_WRONLY|os.O_TRUNC, 0644)
+			Expect(err).Should(Succeed())
+			time.Sleep(10 * time.Millisecond)
+			_, err = f.WriteString("host: mongodb://server:27017/monkey")
+			Expect(err).Should(Succeed())
+			Expect(f.Close()).Should(Succeed())
+
+			Eventually(func() string { return getHost(c) }).Should(Equal("mongodb://server:27017/monkey"))
+			Expect(atomic.LoadInt32(&errs) == 0).Should(BeTrue())
+
+			// 清空后没有写入时报告错误，保留原配置
+			writeFile(file, "")
+			Eventually(func() int32 { return atomic.LoadInt32(&errs) }).Should(BeNumerically("==", 1))
+			Expect(getHost(c) == "mongodb://server:27017/monkey").Should(BeTrue())
+
+			failures := c.Failures()
+			Expect(len(failures) == 1 && errors.Is(failures[0].Err, primitive.ErrEmptyConfig)).Should(BeTrue())
+		})
+
+		It("symlink swap", func() {
+			// 模拟kubelet更新ConfigMap: mongo.yaml -> ..data/mongo.yaml, ..data -> v1
+			Expect(os.Mkdir(filepath.Join(dir, "v1"), 0755)).Should(Succeed())
+			Expect(os.Mkdir(filepath.Join(dir, "v2"), 0755)).Should(Succeed())
+			writeFile(filepath.Join(dir, "v1", "mongo.yaml"), "host: mongodb://localhost:27017/monkey")
+			writeFile(filepath.Join(dir, "v2", "mongo.yaml"), "host: mongodb://server:27017/monkey")
+
+			Expect(os.Symlink("v1", filepath.Join(dir, "..data"))).Should(Succeed())
+			Expect(os.Symlink(filepath.Join("..data", "mongo.yaml"), filepath.Join(dir, "mongo.yaml"))).Should(Succeed())
+
+			c := NewConfigIns()
+			err := c.RegisterFile(filepath.Join(dir, "mongo.yaml"), &MongoConf{}, primitive.WithWatch())
+			Expect(err).Should(Succeed())
+			Expect(getHost(c) == "mongodb://localhost:27017/monkey").Should(BeTrue())
+
+			Expect(os.Symlink("v2", filepath.Join(dir, "..data_tmp"))).Should(Succeed())
+			Expect(os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))).Should(Succeed())
+
+			Eventually(func() string { return getHost(c) }, time.Second).Should(Equal("mongodb://server:27017/monkey"))
+		})
+
+		It("no watch", func() {
+			file := filepath.Join(dir, "mongo.yaml")
+			writeFile(file, "host: mongodb://localhost:27017/monkey")
+
+			c := NewConfigIns()
+			err := c.RegisterFile(file, &MongoConf{})
+			Expect(err).Should(Succeed())
+
+			writeFile(file, "host: mongodb://server:27017/monkey")
+			Consistently(func() string { return getHost(c) }, 200*time.Millisecond).Should(Equal("mongodb://localhost:27017/monkey"))
+		})
+	})
+})
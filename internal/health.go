@@ -0,0 +1,125 @@
+package internal
+
+import (
+	. "config/primitive"
+	"sort"
+	"sync"
+	"time"
+)
+
+// healthState nacos连接的健康状态
+type healthState struct {
+	mutex     sync.Mutex
+	state     ConnState
+	err       error
+	lastCheck time.Time
+	syncs     map[string]time.Time // listenKey -> 最近一次同步成功的时间
+	stale     map[string]time.Time // listenKey -> 使用的本地快照的获取时间
+}
+
+// Health 探测nacos服务端，返回连接状态及已注册的dataID和group最近一次同步成功的时间
+//  探测查询WithProbe指定的dataID和group，不修改服务端数据
+func (c *configIns) Health() Health {
+	s := c.load()
+	if s.client != nil && !s.closed {
+		c.setProbe(probe(s.client, c.options.ProbeDataID, c.options.ProbeGroup))
+	}
+
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+
+	h := Health{State: c.health.state, Err: c.health.err, LastCheck: c.health.lastCheck}
+	for key, l := range s.listeners {
+		at, stale := c.health.stale[key]
+		h.Syncs = append(h.Syncs, SyncStatus{
+			DataID:     l.dataID,
+			Group:      l.group,
+			LastSync:   c.health.syncs[key],
+			Stale:      stale,
+			SnapshotAt: at,
+		})
+	}
+
+	sort.Slice(h.Syncs, func(i, j int) bool {
+		if h.Syncs[i].DataID != h.Syncs[j].DataID {
+			return h.Syncs[i].DataID < h.Syncs[j].DataID
+		}
+
+		return h.Syncs[i].Group < h.Syncs[j].Group
+	})
+
+	return h
+}
+
+// setProbe 记录探测结果
+func (c *configIns) setProbe(err error) {
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+
+	c.health.lastCheck = time.Now()
+	c.health.err = err
+	if err != nil {
+		c.health.state = Unreachable
+	} else {
+		c.health.state = Connected
+	}
+}
+
+// reachable 确认服务端可用，用于拉取配置之前
+//  一个实例只有一个客户端，探测成功后不再为每个dataID重复探测，直到连接失败或Health探测失败
+func (c *configIns) reachable(client INacosClient) error {
+	c.health.mutex.Lock()
+	connected := c.health.state == Connected
+	c.health.mutex.Unlock()
+
+	if connected {
+		return nil
+	}
+
+	err := probe(client, c.options.ProbeDataID, c.options.ProbeGroup)
+	c.setProbe(err)
+	return err
+}
+
+// getFailed 拉取配置时连接失败，记录为不可用，下次拉取前重新探测
+func (c *configIns) getFailed(err error) error {
+	if retryable(err) {
+		c.setProbe(err)
+	}
+
+	return err
+}
+
+// synced 从服务端获取到内容并成功更新后，记录同步时间并保存快照
+func (c *configIns) synced(name string, source Flag, namespace, dataID, group, content string) {
+	c.markSync(namespace, dataID, group)
+	if err := c.saveSnapshot(namespace, dataID, group, content); err != nil {
+		c.reportError(name, source, err)
+	}
+}
+
+// markSync 记录dataID和group同步成功的时间
+func (c *configIns) markSync(namespace, dataID, group string) {
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+
+	if c.health.syncs == nil {
+		c.health.syncs = make(map[string]time.Time)
+	}
+
+	key := listenKey(namespace, dataID, group)
+	c.health.syncs[key] = time.Now()
+	delete(c.health.stale, key)
+}
+
+// markStale 记录dataID和group使用了本地快照，at为快照的获取时间
+func (c *configIns) markStale(namespace, dataID, group string, at time.Time) {
+	c.health.mutex.Lock()
+	defer c.health.mutex.Unlock()
+
+	if c.health.stale == nil {
+		c.health.stale = make(map[string]time.Time)
+	}
+
+	c.health.stale[listenKey(namespace, dataID, group)] = at
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/nacos-group/nacos-sdk-go/common/nacos_error"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failClient 探测时返回指定错误的nacos客户端
+type failClient struct {
+	*configtest.Client
+	err error
+}
+
+func (fc *failClient) SearchConfig(param vo.SearchConfigParam) (*model.ConfigPage, error) {
+	return nil, fc.err
+}
+
+var _ = Describe("Health", func() {
+	It("state & syncs", func() {
+		c := NewConfigIns()
+		Expect(c.Health().State == primitive.NotConnected).Should(BeTrue())
+
+		client := configtest.NewClient()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		client.PublishConfig(vo.ConfigParam{DataId: "redis", Group: group, Content: "host: a"})
+
+		Expect(c.UseNacosClient(client, "")).Should(Succeed())
+		Expect(c.RegisterNacos(dataID, group)).Should(Succeed())
+		Expect(c.RegisterMixedWithName("redis", "mixed.yaml", "redis", group, &MongoConf{})).Should(Succeed())
+
+		h := c.Health()
+		Expect(h.State == primitive.Connected).Should(BeTrue())
+		Expect(h.Err).Should(BeNil())
+		Expect(h.LastCheck.IsZero()).Should(BeFalse())
+		Expect(h.Syncs).Should(HaveLen(2))
+		Expect(h.Syncs[0].DataID == dataID && h.Syncs[1].DataID == "redis").Should(BeTrue())
+
+		last := h.Syncs[0].LastSync
+		Expect(last.IsZero()).Should(BeFalse())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(c.Health().Syncs[0].LastSync.After(last)).Should(BeTrue())
+	})
+
+	It("unreachable", func() {
+		c := NewConfigIns()
+		client := &failClient{Client: configtest.NewClient(), err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+		Expect(c.UseNacosClient(client, "")).Should(Succeed())
+
+		h := c.Health()
+		Expect(h.State == primitive.Unreachable).Should(BeTrue())
+		Expect(errors.Is(h.Err, primitive.ErrConnectFailed)).Should(BeTrue())
+		Expect(errors.Is(h.Err, primitive.ErrNetwork)).Should(BeTrue())
+
+		client.err = nil
+		Expect(c.Health().State == primitive.Connected).Should(BeTrue())
+	})
+
+	It("probe dataID", func() {
+		var probed vo.SearchConfigParam
+		client := &probeClient{Client: configtest.NewClient(), f: func(param vo.SearchConfigParam) { probed = param }}
+
+		c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithProbe("health", "ops"))
+		c.Health()
+		Expect(probed.DataId == "health" && probed.Group == "ops").Should(BeTrue())
+	})
+
+	It("connect error", func() {
+		kinds := map[error]error{
+			&net.DNSError{Err: "no such host", Name: "nacos.local"}:             primitive.ErrDNS,
+			&net.OpError{Op: "dial", Err: errors.New("i/o timeout")}:            primitive.ErrNetwork,
+			fmt.Errorf("get config: %w", &net.OpError{Op: "read", Err: io.EOF}): primitive.ErrNetwork,
+			errors.New("server list is empty"):                                  primitive.ErrNetwork,
+			errors.New("get config forbidden"):                                  primitive.ErrAuthFailed,
+			nacos_error.NewNacosError("401", "unauthorized", nil):               primitive.ErrAuthFailed,
+			nacos_error.NewNacosError("403", "invalid access key", nil):         primitive.ErrAuthFailed,
+			nacos_error.NewNacosError("500", "internal server error", nil):      nil,
+			errors.New("[client.GetConfig] param.dataId can not be empty"):      nil,
+		}
+
+		for err, kind := range kinds {
+			ce := &primitive.ConnectError{}
+			Expect(errors.As(connectError(err), &ce)).Should(BeTrue())
+			Expect(ce.Kind == kind).Should(BeTrue())
+			Expect(errors.Is(ce, primitive.ErrConnectFailed)).Should(BeTrue())
+			Expect(errors.Is(ce, err)).Should(BeTrue())
+
+			// 只有网络不可用、域名解析失败时重试
+			Expect(retryable(ce) == (kind == primitive.ErrNetwork || kind == primitive.ErrDNS)).Should(BeTrue())
+		}
+
+		Expect(connectError(nil)).Should(BeNil())
+	})
+
+	It("probe once", func() {
+		var probes int32
+		client := &probeClient{Client: configtest.NewClient(), f: func(param vo.SearchConfigParam) { atomic.AddInt32(&probes, 1) }}
+		client.PublishConfig(vo.ConfigParam{DataId: "a", Group: group, Content: "host: a"})
+		client.PublishConfig(vo.ConfigParam{DataId: "b", Group: group, Content: "host: b"})
+		client.PublishConfig(vo.ConfigParam{DataId: "c", Group: group, Content: "host: c"})
+
+		c := NewConfigIns(primitive.WithNacosClient(client, ""))
+		Expect(c.RegisterNacosStructWithName("a", "a", group, &MongoConf{})).Should(Succeed())
+		Expect(c.RegisterNacosStructWithName("b", "b", group, &MongoConf{})).Should(Succeed())
+		Expect(atomic.LoadInt32(&probes) == 1).Should(BeTrue())
+
+		// 拉取配置连接失败后重新探测
+		c.getFailed(connectError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+		Expect(c.RegisterNacosStructWithName("c", "c", group, &MongoConf{})).Should(Succeed())
+		Expect(atomic.LoadInt32(&probes) == 2).Should(BeTrue())
+	})
+
+	It("dns", func() {
+		c := NewConfigIns()
+		err := c.DailNacos("nacos.invalid:8848", "")
+		Expect(errors.Is(err, primitive.ErrDNS)).Should(BeTrue())
+		Expect(c.Health().State == primitive.Unreachable).Should(BeTrue())
+	})
+})
+
+// probeClient 记录探测参数的nacos客户端
+type probeClient struct {
+	*configtest.Client
+	f func(param vo.SearchConfigParam)
+}
+
+func (pc *probeClient) SearchConfig(param vo.SearchConfigParam) (*model.ConfigPage, error) {
+	pc.f(param)
+	return pc.Client.SearchConfig(param)
+}
@@ -6,8 +6,7 @@ import (
 	"io/ioutil"
 	"reflect"
 	"sync"
-
-	"gopkg.in/yaml.v2"
+	"sync/atomic"
 
 	"github.com/nacos-group/nacos-sdk-go/vo"
 )
@@ -15,35 +14,182 @@ import (
 const defaultName = "default"
 
 type configIns struct {
-	mutex sync.RWMutex
+	options *ConfigOptions // 创建实例时的选项
+
+	mutex sync.RWMutex // 保护errorHandler、subscribers
+
+	regMutex sync.Mutex   // 注册操作互斥，保证注册信息的检查与写入是原子的
+	snap     atomic.Value // *snapshot，当前的注册信息，读取时无锁
+	pubMutex sync.Mutex   // 发布、删除操作互斥，缩小比较与写入之间的窗口
+
+	errorHandler ErrorHandler             // 热更新失败时的回调
+	subscribers  map[*subscriber]struct{} // 配置变化的订阅者
+
+	done      chan struct{} // Close时关闭，用于停止重试及后台任务
+	closeOnce sync.Once     // 保证done只关闭一次
+
+	health   healthState  // nacos连接的健康状态
+	failures failureState // 热更新失败的记录
+}
 
+// snapshot 注册信息快照，写时复制，发布后不再修改
+type snapshot struct {
 	namespace string       // nacos客户端访问的namespace
 	client    INacosClient // nacos客户端
+	owned     bool         // client由DailNacos创建，Close时释放；注入的客户端可能被共享，不释放
+
+	mixed   map[string]*mixedConfig
+	files   map[string]*fileConfig
+	nacos   map[string]*nacosConfig
+	layered map[string]*layeredConfig
+
+	listeners map[string]*listener // nacos和混合模式已监听的dataID和group
+
+	closed bool // 已调用Close，不再允许注册
+}
+
+// clone 复制快照，map中的配置对象共享
+func (s *snapshot) clone() *snapshot {
+	cp := &snapshot{
+		namespace: s.namespace,
+		client:    s.client,
+		owned:     s.owned,
+		mixed:     make(map[string]*mixedConfig, len(s.mixed)+1),
+		files:     make(map[string]*fileConfig, len(s.files)+1),
+		nacos:     make(map[string]*nacosConfig, len(s.nacos)+1),
+		layered:   make(map[string]*layeredConfig, len(s.layered)+1),
+		listeners: make(map[string]*listener, len(s.listeners)+1),
+		closed:    s.closed,
+	}
+
+	for k, v := range s.mixed {
+		cp.mixed[k] = v
+	}
+
+	for k, v := range s.files {
+		cp.files[k] = v
+	}
+
+	for k, v := range s.nacos {
+		cp.nacos[k] = v
+	}
+
+	for k, v := range s.layered {
+		cp.layered[k] = v
+	}
 
-	mixed map[string]interface{}
-	files map[string]interface{}
-	nacos map[string]*nacosConfig
+	for k, v := range s.listeners {
+		cp.listeners[k] = v
+	}
+
+	return cp
+}
+
+// load 获取当前的注册信息，无锁
+func (c *configIns) load() *snapshot {
+	return c.snap.Load().(*snapshot)
+}
+
+// commit 复制当前的注册信息，修改后替换，调用方需持有regMutex
+func (c *configIns) commit(f func(s *snapshot)) {
+	s := c.load().clone()
+	f(s)
+	c.snap.Store(s)
 }
 
 // DailNacos 注册nacos客户端
+//  客户端选项依次为：默认选项、NewConfig指定的选项、opts
+//  服务端不可用时按照WithRetry重试，指定WithLazyDial时重试后仍不可用也不返回错误（鉴权失败除外）
 func (c *configIns) DailNacos(addr, namespace string, opts ...ClientOption) error {
-	if c.client != nil {
-		return errors.New("nacos client has been init")
+	if err := c.checkClient(); err != nil {
+		return err
 	}
 
-	client, err := NewNacosClient(addr, namespace, opts...)
+	opts = append(clientOptions(c.options), opts...)
+
+	var client INacosClient
+	err := retry(c.options.Retry, c.done, func() error {
+		if client == nil {
+			cl, err := NewNacosClient(addr, namespace, opts...)
+			if err != nil {
+				return err
+			}
+
+			client = cl
+		}
+
+		return probe(client, c.options.ProbeDataID, c.options.ProbeGroup)
+	})
+
+	c.setProbe(err)
 	if err != nil {
+		if !c.options.LazyDial || !retryable(err) {
+			return err
+		}
+
+		if client == nil {
+			if client, err = newNacosClient(addr, namespace, opts...); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 重试时不持有regMutex，之后再次检查是否已关闭或已设置客户端
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	if err := c.checkClient(); err != nil {
+		return err
+	}
+
+	c.commit(func(s *snapshot) {
+		s.client = client
+		s.namespace = namespace
+		s.owned = true
+	})
+
+	return nil
+}
+
+// UseNacosClient 使用已创建的nacos客户端，e.g. 共享的客户端、包装过的客户端
+//  namespace需要与客户端访问的namespace一致
+func (c *configIns) UseNacosClient(client INacosClient, namespace string) error {
+	if client == nil {
+		return errors.New("nacos client is nil")
+	}
+
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	if err := c.checkClient(); err != nil {
 		return err
 	}
 
-	c.client = client
-	c.namespace = namespace
+	c.commit(func(s *snapshot) {
+		s.client = client
+		s.namespace = namespace
+	})
+
+	return nil
+}
+
+// checkClient 检查是否可以设置nacos客户端，设置前调用方需持有regMutex再次检查
+func (c *configIns) checkClient() error {
+	s := c.load()
+	if s.closed {
+		return ErrClosed
+	}
+
+	if s.client != nil {
+		return errors.New("nacos client has been init")
+	}
+
 	return nil
 }
 
 // RegisterConfig 注册配置文件
-func (c *configIns) RegisterFile(file string, v interface{}) error {
-	return c.RegisterFileWithName(defaultName, file, v)
+func (c *configIns) RegisterFile(file string, v interface{}, opts ...RegisterOption) error {
+	return c.RegisterFileWithName(defaultName, file, v, opts...)
 }
 
 // RegisterNacos 注册nacos dataID和group
@@ -51,96 +197,179 @@ func (c *configIns) RegisterNacos(dataID, group string) error {
 	return c.RegisterNacosWithName(defaultName, dataID, group)
 }
 
-// RegisterMixed 注册可更新配置
-func (c *configIns) RegisterMixed(file, dataID, group string, v IMixedConfig) error {
-	return c.RegisterMixedWithName(defaultName, file, dataID, group, v)
+// RegisterNacosStruct 注册nacos dataID和group，配置内容解析为v的类型
+func (c *configIns) RegisterNacosStruct(dataID, group string, v interface{}, opts ...RegisterOption) error {
+	return c.RegisterNacosStructWithName(defaultName, dataID, group, v, opts...)
+}
+
+// RegisterMixed 注册可更新配置，v为任意struct指针
+//  nacos内容按照WithMerge指定的方式合并到配置文件的内容上；v实现了OnNacosChanged时由其自行解析nacos内容，
+//  实现了UpdateAfterRegister时注册成功后调用
+func (c *configIns) RegisterMixed(file, dataID, group string, v interface{}, opts ...RegisterOption) error {
+	return c.RegisterMixedWithName(defaultName, file, dataID, group, v, opts...)
 }
 
 // RegisterConfig 注册配置文件
-func (c *configIns) RegisterFileWithName(name string, file string, v interface{}) error {
+//  指定WithWatch()时，文件变更后会重新加载配置
+func (c *configIns) RegisterFileWithName(name string, file string, v interface{}, opts ...RegisterOption) error {
 	if err := checkType(v); err != nil {
 		return err
 	}
 
-	if _, exist := c.files[name]; exist {
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	s := c.load()
+	if s.closed {
+		return ErrClosed
+	}
+
+	if _, exist := s.files[name]; exist {
 		return ErrAlreadyRegister
 	}
 
-	data, err := ioutil.ReadFile(file)
+	options := NewRegisterOptions(opts...)
+	fileConf, err := newFileConfig(file, v, options)
 	if err != nil {
 		return err
 	}
 
-	// copy出一个新的空对象，由于存储配置信息
-	fileConf, err := copyAndUnmarshal(data, v)
-	if err != nil {
-		return err
+	if options.Watch {
+		err = fileConf.watch(func(old, new interface{}) {
+			c.notify(name, old, new)
+		}, func(payload string, err error) {
+			c.failUpdate(name, OnlyFile, payload, err)
+		})
+
+		if err != nil {
+			return err
+		}
 	}
 
-	c.files[name] = fileConf
+	c.commit(func(s *snapshot) {
+		s.files[name] = fileConf
+	})
+
 	return nil
 }
 
 // RegisterNacos 注册nacos dataID和group
 func (c *configIns) RegisterNacosWithName(name, dataID, group string) error {
-	if isRegistered(dataID, group) {
-		return ErrDataIDAndGroupAlreadyRegister
+	return c.registerNacos(name, dataID, group, nil, NewRegisterOptions())
+}
+
+// RegisterNacosStructWithName 注册nacos dataID和group，配置内容解析为v的类型
+//  注册及配置变更时解析为新的对象，读取时无需再次解析
+//  配置格式由WithFormat指定，未指定时根据dataID的扩展名识别，默认为yaml
+func (c *configIns) RegisterNacosStructWithName(name, dataID, group string, v interface{}, opts ...RegisterOption) error {
+	if err := checkType(v); err != nil {
+		return err
 	}
 
-	content, err := c.client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
-	if err != nil {
+	return c.registerNacos(name, dataID, group, v, NewRegisterOptions(opts...))
+}
+
+// registerNacos 注册nacos dataID和group，proto为nil时保存原始内容
+func (c *configIns) registerNacos(name, dataID, group string, proto interface{}, options *RegisterOptions) error {
+	s := c.load()
+	if _, err := s.checkNacos(s.nacos[name] != nil, dataID, group, options); err != nil {
 		return err
 	}
 
-	if content == "" {
-		return ErrNotExistConfig
+	// 拉取时不持有regMutex，服务端不可用时的重试不会阻塞其他注册及Close
+	content, err := c.fetch(s.client, dataID, group)
+	if err == nil && content == "" {
+		err = ErrNotExistConfig
 	}
 
-	err = c.client.ListenConfig(vo.ConfigParam{
-		DataId: dataID,
-		Group:  group,
-		OnChange: func(namespace, group, dataId, data string) {
-			if namespace != c.namespace {
-				return
-			}
+	// 服务端不可用时使用本地快照，懒注册时使用fallback，之后在后台填充
+	saved, err := c.fallbackSnapshot(name, OnlyNacos, s.namespace, dataID, group, err)
+	if saved != nil {
+		content = saved.Content
+	}
 
-			for _, conf := range c.nacos {
-				if conf.dataID == dataID && conf.group == group {
-					conf.mutex.Lock()
-					conf.content = data
-					conf.mutex.Unlock()
-					break
-				}
-			}
-		},
-	})
+	lazy := err != nil && options.Lazy
+	if err != nil && !lazy {
+		return err
+	}
 
+	codec, err := codecByDataID(dataID, options.Format)
 	if err != nil {
 		return err
 	}
 
-	markRegistered(dataID, group)
-	c.nacos[name] = &nacosConfig{
+	nacosConf := &nacosConfig{
 		dataID:  dataID,
 		group:   group,
-		content: content,
+		proto:   proto,
+		codec:   codec,
+		verify:  options.Format != "" || hasFormat(dataID),
+		options: options,
+		ready:   newReadiness(!lazy && saved == nil),
+	}
+
+	if lazy {
+		err = nacosConf.fallback(options.Fallback)
+	} else {
+		_, _, err = nacosConf.update(content)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	// 拉取期间可能已关闭，或注册了同名的配置、相同的dataID和group
+	s = c.load()
+	listened, err := s.checkNacos(s.nacos[name] != nil, dataID, group, options)
+	if err != nil {
+		return err
+	}
+
+	// 在监听及后台填充之前标记，之后获取到服务端的内容时清除，避免覆盖同步成功的记录
+	if saved != nil {
+		c.markStale(s.namespace, dataID, group, saved.UpdatedAt)
+	}
+
+	if !listened {
+		if err = c.listen(s.client, dataID, group); err != nil {
+			return err
+		}
+	}
+
+	c.commit(func(s *snapshot) {
+		s.nacos[name] = nacosConf
+		s.addListener(dataID, group, name, OnlyNacos)
+	})
+
+	if lazy || saved != nil {
+		c.fill(nacosConf.ready, dataID, group, func(s *snapshot) bool {
+			return s.nacos[name] == nacosConf
+		})
+	} else {
+		c.synced(name, OnlyNacos, s.namespace, dataID, group, content)
 	}
 
 	return nil
 }
 
 // RegisterMixedWithName 注册可更新配置
-func (c *configIns) RegisterMixedWithName(name, file, dataID, group string, v IMixedConfig) error {
+func (c *configIns) RegisterMixedWithName(name, file, dataID, group string, v interface{}, opts ...RegisterOption) error {
 	if err := checkType(v); err != nil {
 		return err
 	}
 
-	if _, exist := c.mixed[name]; exist {
-		return ErrAlreadyRegister
+	s := c.load()
+	options := NewRegisterOptions(opts...)
+	if _, err := s.checkNacos(s.mixed[name] != nil, dataID, group, options); err != nil {
+		return err
 	}
 
-	if c.client == nil {
-		return ErrDialNacosFirst
+	codec, err := codecByFile(file)
+	if err != nil {
+		return err
 	}
 
 	data, err := ioutil.ReadFile(file)
@@ -149,54 +378,92 @@ func (c *configIns) RegisterMixedWithName(name, file, dataID, group string, v IM
 	}
 
 	// copy出一个新的空对象，由于存储配置信息
-	cp, err := copyAndUnmarshal(data, v)
+	cp, err := decodeConfig(codec, data, v, options)
 	if err != nil {
 		return err
 	}
 
-	mixedConf, ok := cp.(IMixedConfig)
-	if !ok {
-		return ErrCopyException
+	mixedConf := &mixedConfig{
+		dataID:  dataID,
+		group:   group,
+		file:    file,
+		codec:   codec,
+		data:    data,
+		options: options,
+		proto:   v,
+		base:    deepCopy(cp),
+	}
+	mixedConf.value.Store(cp)
+
+	// 从nacos拉取配置信息，服务端不可用时使用本地快照，懒注册时先使用配置文件的内容，之后在后台填充
+	//  与registerNacos一致，拉取时不持有regMutex
+	content, err := c.fetch(s.client, dataID, group)
+	saved, err := c.fallbackSnapshot(name, Mixed, s.namespace, dataID, group, err)
+	if saved != nil {
+		content = saved.Content
 	}
 
-	// 从nacos拉取配置信息
-	content, err := c.client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
-	if err != nil {
+	lazy := options.Lazy && (err != nil || content == "")
+	if err != nil && !lazy {
 		return err
 	}
 
-	err = mixedConf.OnNacosChanged(c.namespace, group, dataID, content)
-	if err != nil {
-		return err
+	mixedConf.ready = newReadiness(!lazy && saved == nil)
+	if !lazy {
+		if _, _, err = mixedConf.update(s.namespace, group, dataID, content); err != nil {
+			return err
+		}
 	}
 
-	err = c.client.ListenConfig(vo.ConfigParam{
-		DataId:   dataID,
-		Group:    group,
-		OnChange: c.onChange,
-	})
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
 
+	s = c.load()
+	listened, err := s.checkNacos(s.mixed[name] != nil, dataID, group, options)
 	if err != nil {
 		return err
 	}
 
-	mixedConf.UpdateAfterRegister()
+	// 在监听及后台填充之前标记，之后获取到服务端的内容时清除，避免覆盖同步成功的记录
+	if saved != nil {
+		c.markStale(s.namespace, dataID, group, saved.UpdatedAt)
+	}
+
+	if !listened {
+		if err = c.listen(s.client, dataID, group); err != nil {
+			return err
+		}
+	}
 
-	c.mutex.Lock()
-	c.mixed[name] = mixedConf
-	c.mutex.Unlock()
+	// 发布前调用，此时没有读取方
+	if hook, ok := mixedConf.get().(AfterRegisterHook); ok {
+		hook.UpdateAfterRegister()
+	}
+
+	c.commit(func(s *snapshot) {
+		s.mixed[name] = mixedConf
+		s.addListener(dataID, group, name, Mixed)
+	})
+
+	if lazy || saved != nil {
+		c.fill(mixedConf.ready, dataID, group, func(s *snapshot) bool {
+			return s.mixed[name] == mixedConf
+		})
+	} else {
+		c.synced(name, Mixed, s.namespace, dataID, group, content)
+	}
 
 	return nil
 }
 
-// GetConfig 读取顺序：混合模式 -> 文件模式 -> Nacos模式
+// GetConfig 读取顺序：混合模式 -> 文件模式 -> Nacos模式 -> 分层模式
 func (c *configIns) GetConfig() interface{} {
 	conf, _ := c.GetConfigWithFlagByName(defaultName)
 	return conf
 }
 
 // GetFileConfig 获取文件模式的配置信息
-//  为了提升性能，返回值为引用，外部禁止修改
+//  返回的配置发布后不再修改，外部禁止修改；需要修改时注册时指定WithDeepCopy()
 func (c *configIns) GetFileConfig() interface{} {
 	return c.GetFileConfigByName(defaultName)
 }
@@ -207,56 +474,50 @@ func (c *configIns) GetNacosConfig() interface{} {
 }
 
 // GetMixedConfig 获取混合模式的配置信息
-//  为了提升性能，返回值为引用，外部禁止修改
+//  返回的配置发布后不再修改，外部禁止修改；需要修改时注册时指定WithDeepCopy()
 func (c *configIns) GetMixedConfig() interface{} {
 	return c.GetMixedConfigByName(defaultName)
 }
 
 // GetConfigWithFlagByName 通过名字获取配置信息
-//  为了提升性能，返回值为引用，外部禁止修改
+//  返回的配置发布后不再修改，外部禁止修改；需要修改时注册时指定WithDeepCopy()
 func (c *configIns) GetConfigByName(name string) interface{} {
 	v, _ := c.GetConfigWithFlagByName(name)
 	return v
 }
 
 // GetFileConfigByName 获取配置文件中的信息
-//  为了提升性能，返回值为引用，外部禁止修改
+//  返回的配置发布后不再修改，外部禁止修改；需要修改时注册时指定WithDeepCopy()
 func (c *configIns) GetFileConfigByName(name string) interface{} {
-	if v, exist := c.files[name]; exist {
-		return v
+	if fc, exist := c.load().files[name]; exist {
+		return readValue(fc.get(), fc.options)
 	}
 
 	return nil
 }
 
-// GetNacosConfigByName 获取nacos模式指定名称的配置信息，值为string
+// GetNacosConfigByName 获取nacos模式指定名称的配置信息
+//  通过RegisterNacosStruct注册时为解析后的对象（外部禁止修改，WithDeepCopy时为副本），否则为string
 func (c *configIns) GetNacosConfigByName(name string) interface{} {
-	conf, exist := c.nacos[name]
-	if c.client == nil || !exist {
+	s := c.load()
+	conf, exist := s.nacos[name]
+	if s.client == nil || !exist {
 		return nil
 	}
 
-	conf.mutex.RLock()
-	content := conf.content
-	conf.mutex.RUnlock()
-
-	return content
+	return readValue(conf.get(), conf.options)
 }
 
 // GetMixedConfigByName 获取混合模式下指定名称的配置信息
 func (c *configIns) GetMixedConfigByName(name string) interface{} {
-	c.mutex.RLock()
-	v, exist := c.mixed[name]
-	c.mutex.RUnlock()
-
-	if exist {
-		return v
+	if mc, exist := c.load().mixed[name]; exist {
+		return readValue(mc.get(), mc.options)
 	}
 
 	return nil
 }
 
-// GetConfigWithFlag 读取顺序：混合模式 -> 文件模式 -> Nacos模式
+// GetConfigWithFlag 读取顺序：混合模式 -> 文件模式 -> Nacos模式 -> 分层模式
 func (c *configIns) GetConfigWithFlag() (interface{}, Flag) {
 	return c.GetConfigWithFlagByName(defaultName)
 }
@@ -272,6 +533,7 @@ func (c *configIns) GetConfigWithFlagByName(name string) (interface{}, Flag) {
 		{c.GetMixedConfigByName, Mixed},
 		{c.GetFileConfigByName, OnlyFile},
 		{c.GetNacosConfigByName, OnlyNacos},
+		{c.GetLayeredConfigByName, Layered},
 	}
 
 	for _, gt := range getters {
@@ -284,37 +546,196 @@ func (c *configIns) GetConfigWithFlagByName(name string) (interface{}, Flag) {
 	return nil, Unknown
 }
 
-// onChange nacos配置发生变化触发该回调函数
+// OnError 注册热更新失败时的回调
+func (c *configIns) OnError(h ErrorHandler) {
+	c.mutex.Lock()
+	c.errorHandler = h
+	c.mutex.Unlock()
+}
+
+// reportError 通知热更新失败
+func (c *configIns) reportError(name string, source Flag, err error) {
+	c.mutex.RLock()
+	h := c.errorHandler
+	c.mutex.RUnlock()
+
+	if h != nil {
+		h(name, source, err)
+	}
+}
+
+// checkNacos 检查是否可以注册使用nacos的配置，exist为名称是否已注册；返回dataID和group是否已被监听
+func (s *snapshot) checkNacos(exist bool, dataID, group string, options *RegisterOptions) (bool, error) {
+	if s.closed {
+		return false, ErrClosed
+	}
+
+	if s.client == nil {
+		return false, ErrDialNacosFirst
+	}
+
+	if exist {
+		return false, ErrAlreadyRegister
+	}
+
+	if dataID == "" || group == "" {
+		return false, ErrEmptyDataIDOrGroup
+	}
+
+	return s.checkListener(dataID, group, options)
+}
+
+// checkListener 检查dataID和group是否已被当前实例监听，已监听时需要指定WithSharedListener才能共享
+func (s *snapshot) checkListener(dataID, group string, options *RegisterOptions) (bool, error) {
+	if _, exist := s.listeners[listenKey(s.namespace, dataID, group)]; !exist {
+		return false, nil
+	}
+
+	if !options.SharedListener {
+		return false, ErrDataIDAndGroupAlreadyRegister
+	}
+
+	return true, nil
+}
+
+// addListener 记录dataID和group的监听及监听它的配置名称，用于变化时直接分发
+func (s *snapshot) addListener(dataID, group, name string, flag Flag) {
+	key := listenKey(s.namespace, dataID, group)
+
+	l := &listener{namespace: s.namespace, dataID: dataID, group: group}
+	if old, exist := s.listeners[key]; exist {
+		*l = *old
+	}
+
+	switch flag {
+	case OnlyNacos:
+		l.nacos = append(l.nacos[:len(l.nacos):len(l.nacos)], name)
+	case Mixed:
+		l.mixed = append(l.mixed[:len(l.mixed):len(l.mixed)], name)
+	case Layered:
+		l.layered = append(l.layered[:len(l.layered):len(l.layered)], name)
+	}
+
+	s.listeners[key] = l
+}
+
+// listen 监听nacos配置变化，同一dataID和group只监听一次
+func (c *configIns) listen(client INacosClient, dataID, group string) error {
+	return client.ListenConfig(vo.ConfigParam{
+		DataId:   dataID,
+		Group:    group,
+		OnChange: c.onChange,
+	})
+}
+
+// onChange nacos配置发生变化触发该回调函数，只更新监听该namespace、dataID和group的配置
 func (c *configIns) onChange(namespace, group, dataID, data string) {
-	for _, obj := range c.mixed {
-		conf, ok := obj.(IMixedConfig)
-		if !ok {
-			continue
+	s := c.load()
+	l, exist := s.listeners[listenKey(namespace, dataID, group)]
+	if !exist {
+		return
+	}
+
+	// 至少一个配置更新成功时记录同步时间并保存快照
+	var (
+		updated string
+		source  Flag
+	)
+
+	// 配置被删除时以空内容触发，nacos模式保留上一次有效的配置，不作为更新失败
+	nacos := l.nacos
+	if data == "" {
+		nacos = nil
+	}
+
+	for _, name := range nacos {
+		conf := s.nacos[name]
+		old, new, err := conf.update(data)
+		if err != nil {
+			c.failUpdate(name, OnlyNacos, data, err)
+		} else {
+			updated, source = name, OnlyNacos
+			conf.ready.done()
+			c.notify(name, old, new)
+		}
+	}
+
+	for _, name := range l.mixed {
+		conf := s.mixed[name]
+		old, new, err := conf.update(namespace, group, dataID, data)
+		if err != nil {
+			c.failUpdate(name, Mixed, data, err)
+		} else {
+			updated, source = name, Mixed
+			conf.ready.done()
+			c.notify(name, old, new)
 		}
+	}
+
+	for _, name := range l.layered {
+		if c.updateLayered(name, s.layered[name], dataID, group, data) {
+			updated, source = name, Layered
+		}
+	}
 
-		c.mutex.Lock()
-		conf.OnNacosChanged(namespace, group, dataID, data)
-		c.mutex.Unlock()
+	if updated != "" {
+		c.synced(updated, source, namespace, dataID, group, data)
 	}
 }
 
-// copyAndUnmarshal 复制一个新对象，然后在进行反序列化
-func copyAndUnmarshal(data []byte, v interface{}) (interface{}, error) {
-	empty := reflect.New(reflect.Indirect(reflect.ValueOf(v)).Type())
-	emptyObject := empty.Interface()
+// decodeConfig 按照注册选项解析配置内容，生成新的配置对象
+func decodeConfig(codec Codec, data []byte, v interface{}, options *RegisterOptions) (interface{}, error) {
+	if options.UseProfile {
+		block, err := selectProfile(codec, data, options)
+		if err != nil {
+			return nil, err
+		}
 
-	cp := reflect.New(reflect.Indirect(reflect.ValueOf(v)).Type())
-	cpObject := cp.Interface()
+		data = block
+	}
 
-	err := yaml.Unmarshal(data, cpObject)
+	conf, err := copyAndUnmarshal(codec, data, v)
 	if err != nil {
 		return nil, err
 	}
 
-	if reflect.DeepEqual(cpObject, emptyObject) {
+	if options.Env {
+		if err = applyEnv(conf, options.EnvPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = validate(conf); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// copyAndUnmarshal 复制一个新对象，设置默认值后再使用codec进行反序列化
+//  是否为空按照未设置默认值的解析结果判断，配置的值与default tag相同时不是空配置
+func copyAndUnmarshal(codec Codec, data []byte, v interface{}) (interface{}, error) {
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+
+	plain := reflect.New(t)
+	if err := codec.Unmarshal(data, plain.Interface()); err != nil {
+		return nil, err
+	}
+
+	if plain.Elem().IsZero() {
 		return nil, ErrEmptyConfig
 	}
 
+	cp := reflect.New(t)
+	if err := applyDefaults(cp); err != nil {
+		return nil, err
+	}
+	cpObject := cp.Interface()
+
+	if err := codec.Unmarshal(data, cpObject); err != nil {
+		return nil, err
+	}
+
 	return cpObject, nil
 }
 
@@ -327,10 +748,26 @@ func checkType(a interface{}) error {
 	return nil
 }
 
-func NewConfigIns() *configIns {
-	return &configIns{
-		files: make(map[string]interface{}),
-		mixed: make(map[string]interface{}),
-		nacos: make(map[string]*nacosConfig),
+func NewConfigIns(opts ...ConfigOption) *configIns {
+	options := NewConfigOptions(opts...)
+	c := &configIns{
+		options:     options,
+		subscribers: make(map[*subscriber]struct{}),
+		done:        make(chan struct{}),
 	}
+
+	c.snap.Store(&snapshot{
+		namespace: options.Namespace,
+		client:    options.Client,
+
+		files: make(map[string]*fileConfig),
+		mixed: make(map[string]*mixedConfig),
+		nacos: make(map[string]*nacosConfig),
+
+		layered: make(map[string]*layeredConfig),
+
+		listeners: make(map[string]*listener),
+	})
+
+	return c
 }
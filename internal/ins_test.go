@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"config/configtest"
 	"config/primitive"
 	"fmt"
 	"sync"
@@ -184,6 +185,15 @@ func (cc *GeneralConfig) GetConfig() *Configure {
 	return cc.Configs[cc.CurEnv]
 }
 
+const mixedContent = "host: mongodb://server:27017/monkey\nmax_pool_size: 150"
+
+// newMixedIns 创建使用内存nacos客户端的实例，并发布混合模式的nacos配置
+func newMixedIns() (*configIns, *configtest.Client) {
+	c, client := newTestIns()
+	client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mixedContent})
+	return c, client
+}
+
 var _ = Describe("Ins", func() {
 	Context("file mode", func() {
 		It("default & custom name", func() {
@@ -285,8 +295,6 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("listen changed", func() {
-			clearRegistered()
-
 			c := NewConfigIns()
 
 			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(wSecretKey), primitive.WithAccessKey(wAccessKey))
@@ -313,7 +321,7 @@ var _ = Describe("Ins", func() {
 					cc.Timeout = cc.Timeout + 1
 
 					data, _ := yaml.Marshal(cc)
-					c.client.PublishConfig(vo.ConfigParam{
+					c.load().client.PublishConfig(vo.ConfigParam{
 						DataId:  dataID,
 						Group:   group,
 						Content: string(data),
@@ -340,7 +348,7 @@ var _ = Describe("Ins", func() {
 			wg.Wait()
 
 			originData, _ := yaml.Marshal(oriConf)
-			c.client.PublishConfig(vo.ConfigParam{
+			c.load().client.PublishConfig(vo.ConfigParam{
 				DataId:  dataID,
 				Group:   group,
 				Content: string(originData),
@@ -348,22 +356,18 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("empty nacos address", func() {
-			clearRegistered()
 			c := NewConfigIns()
 			err := c.DailNacos("", namespace, primitive.WithTimeoutMs(100))
 			Expect(err).ShouldNot(Succeed())
 		})
 
 		It("invalidate address", func() {
-			clearRegistered()
 			c := NewConfigIns()
 			err := c.DailNacos("127.0.0.1", namespace, primitive.WithTimeoutMs(100))
 			Expect(err).ShouldNot(Succeed())
 		})
 
 		It("dail twice", func() {
-			clearRegistered()
-
 			c := NewConfigIns()
 			err := c.DailNacos(addr, namespace, primitive.WithAccessKey(accessKey), primitive.WithSecretKey(secretKey))
 			Expect(err).Should(Succeed())
@@ -373,8 +377,6 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("register dataID & group twice", func() {
-			clearRegistered()
-
 			c := NewConfigIns()
 
 			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(wSecretKey), primitive.WithAccessKey(wAccessKey))
@@ -391,8 +393,6 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("register invalidate dataID or group", func() {
-			clearRegistered()
-
 			c := NewConfigIns()
 
 			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(wSecretKey), primitive.WithAccessKey(wAccessKey))
@@ -409,15 +409,11 @@ var _ = Describe("Ins", func() {
 		})
 	})
 
-	XContext("mixed mode", func() {
+	Context("mixed mode", func() {
 		It("normal", func() {
-			clearRegistered()
-			c := NewConfigIns()
+			c, _ := newMixedIns()
 
-			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(secretKey), primitive.WithAccessKey(accessKey))
-			Expect(err).Should(Succeed())
-
-			err = c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
+			err := c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
 			Expect(err).Should(Succeed())
 
 			conf := c.GetMixedConfig().(*MongoConf)
@@ -425,7 +421,7 @@ var _ = Describe("Ins", func() {
 			Expect(conf.MaxPoolSize == 150).Should(BeTrue())
 			Expect(conf.Host == "mongodb://server:27017/monkey").Should(BeTrue())
 
-			err = c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf2{})
+			err = c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf2{}, primitive.WithSharedListener())
 			Expect(err).Should(Succeed())
 
 			conf2 := c.GetMixedConfigByName("app").(*MongoConf2)
@@ -437,19 +433,15 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("no dial nacos", func() {
-			clearRegistered()
 			c := NewConfigIns()
 			err := c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
 			Expect(err).ShouldNot(Succeed())
 		})
 
 		It("duplicate name", func() {
-			clearRegistered()
-			c := NewConfigIns()
-			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(secretKey), primitive.WithAccessKey(accessKey))
-			Expect(err).Should(Succeed())
+			c, _ := newMixedIns()
 
-			err = c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf{})
+			err := c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf{})
 			Expect(err).Should(Succeed())
 
 			err = c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, &MongoConf{})
@@ -457,13 +449,9 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("invalidate type", func() {
-			clearRegistered()
-			c := NewConfigIns()
+			c, _ := newMixedIns()
 
-			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(secretKey), primitive.WithAccessKey(accessKey))
-			Expect(err).Should(Succeed())
-
-			err = c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, MongoConf3{})
+			err := c.RegisterMixedWithName("app", "mixed.yaml", dataID, group, MongoConf3{})
 			Expect(err).ShouldNot(Succeed())
 
 			err = c.RegisterMixedWithName("app1", "mixed111.yaml", dataID, group, &MongoConf{})
@@ -471,69 +459,41 @@ var _ = Describe("Ins", func() {
 		})
 
 		It("listen changed", func() {
-			clearRegistered()
-
-			c := NewConfigIns()
-
-			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(wSecretKey), primitive.WithAccessKey(wAccessKey))
-			Expect(err).Should(Succeed())
+			client := configtest.NewClient(configtest.WithAsync())
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mixedContent})
+			c := NewConfigIns(primitive.WithNacosClient(client, client.Namespace()))
 
-			err = c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
+			err := c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
 			Expect(err).Should(Succeed())
 
-			oriConf := c.GetMixedConfig().(*MongoConf)
+			oriConf := *c.GetMixedConfig().(*MongoConf)
 
-			cc := *oriConf
+			cc := oriConf
 			cc.Timeout = 10000
 
-			wg := sync.WaitGroup{}
-			wg.Add(2)
+			for i := 0; i < 5; i++ {
+				cc.Timeout = cc.Timeout + 1
 
-			go func() {
-				for i := 0; i < 5; i++ {
-					cc.Timeout = cc.Timeout + 1
+				data, _ := yaml.Marshal(cc)
+				client.PublishConfig(vo.ConfigParam{
+					DataId:  dataID,
+					Group:   group,
+					Content: string(data),
+				})
+				client.Wait()
 
-					data, _ := yaml.Marshal(cc)
-					c.client.PublishConfig(vo.ConfigParam{
-						DataId:  dataID,
-						Group:   group,
-						Content: string(data),
-					})
-					time.Sleep(500 * time.Millisecond)
-				}
-				wg.Done()
-			}()
-
-			go func() {
-				for i := 0; i < 5; i++ {
-					time.Sleep(600 * time.Millisecond)
-					conf := c.GetMixedConfig().(*MongoConf)
-
-					Expect(conf.Timeout >= oriConf.Timeout).Should(BeTrue())
-					fmt.Println(conf.Timeout)
-				}
-				wg.Done()
-			}()
-
-			wg.Wait()
-
-			originData, _ := yaml.Marshal(oriConf)
-			c.client.PublishConfig(vo.ConfigParam{
-				DataId:  dataID,
-				Group:   group,
-				Content: string(originData),
-			})
+				conf := c.GetMixedConfig().(*MongoConf)
+				Expect(conf.Timeout == cc.Timeout).Should(BeTrue())
+				Expect(conf.Timeout > oriConf.Timeout).Should(BeTrue())
+			}
 		})
 	})
 
 	Context("get config", func() {
-		XIt("normal", func() {
-			c := NewConfigIns()
-
-			err := c.DailNacos(addr, namespace, primitive.WithSecretKey(secretKey), primitive.WithAccessKey(accessKey))
-			Expect(err).Should(Succeed())
+		It("normal", func() {
+			c, _ := newMixedIns()
 
-			err = c.RegisterFile("mixed.yaml", &MongoConf{})
+			err := c.RegisterFile("mixed.yaml", &MongoConf{})
 			Expect(err).Should(Succeed())
 
 			err = c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})
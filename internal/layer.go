@@ -0,0 +1,703 @@
+package internal
+
+import (
+	. "config/primitive"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v2"
+)
+
+type layeredConfig struct {
+	mutex sync.Mutex // 保证重新合并串行执行
+
+	proto   interface{}      // 注册时传入的对象，用于copy出新的配置对象
+	layers  []Layer          // 按照优先级从低到高排列
+	options *RegisterOptions // 注册选项
+	state   atomic.Value     // *layeredState，当前生效的配置，读取时无锁
+	watches []*fileWatch     // WithWatch时文件层的监听
+}
+
+type layeredState struct {
+	value interface{}  // 合并后解析的对象
+	data  []*layerData // 参与合并的各层的内容
+}
+
+// layerData 层的内容
+type layerData struct {
+	tree  map[string]interface{} // 解析后的内容，nil表示该层为空
+	codec Codec                  // 文件层、nacos层的编解码器
+	raw   []byte                 // 文件层、nacos层的原始内容
+	keys  map[string]string      // 环境变量层、命令行参数层：路径 -> 环境变量或参数的名称
+}
+
+// newLayeredConfig 创建分层配置，各层的内容由apply填充
+func newLayeredConfig(v interface{}, layers []Layer, options *RegisterOptions) *layeredConfig {
+	return &layeredConfig{
+		proto:   v,
+		layers:  layers,
+		options: options,
+	}
+}
+
+// load 获取当前生效的配置及各层的内容
+func (lc *layeredConfig) load() *layeredState {
+	state, _ := lc.state.Load().(*layeredState)
+	return state
+}
+
+// get 获取当前生效的配置
+func (lc *layeredConfig) get() interface{} {
+	if state := lc.load(); state != nil {
+		return state.value
+	}
+
+	return nil
+}
+
+// read 读取nacos层以外的内容
+func (lc *layeredConfig) read(i int) (*layerData, error) {
+	layer := lc.layers[i]
+	switch layer.Source {
+	case Defaults:
+		if layer.Value == nil {
+			return &layerData{}, nil
+		}
+
+		tree, err := toTree(layer.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		zero, err := toTree(reflect.New(reflect.Indirect(reflect.ValueOf(layer.Value)).Type()).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := layerMap(layer, pruneZero(tree, zero))
+		return &layerData{tree: m}, err
+
+	case OnlyFile:
+		data, err := ioutil.ReadFile(layer.File)
+		if os.IsNotExist(err) && layer.Optional {
+			return &layerData{}, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		codec, err := codecByFile(layer.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return lc.parse(layer, codec, data)
+
+	case EnvVars:
+		ld := &layerData{tree: map[string]interface{}{}, keys: map[string]string{}}
+		for path, leaf := range envLeaves(reflect.TypeOf(lc.proto), layer.Prefix) {
+			if value, exist := os.LookupEnv(leaf.name); exist {
+				setPath(ld.tree, leaf.path, leafValue(leaf.t, value))
+				ld.keys[path] = leaf.name
+			}
+		}
+		return ld, nil
+
+	case CmdFlags:
+		ld := &layerData{tree: map[string]interface{}{}, keys: map[string]string{}}
+		layer.FlagSet.Visit(func(f *flag.Flag) {
+			path := strings.Split(f.Name, ".")
+			setPath(ld.tree, path, leafValue(pathType(reflect.TypeOf(lc.proto), path), f.Value.String()))
+			ld.keys[f.Name] = "-" + f.Name
+		})
+		return ld, nil
+	}
+
+	return nil, fmt.Errorf("unknown layer source %d: %s", layer.Source, layer.Name)
+}
+
+// nacosData 解析dataID和group对应的所有nacos层，内容为空时该层为空
+func (lc *layeredConfig) nacosData(dataID, group, content string) (map[int]*layerData, error) {
+	data := map[int]*layerData{}
+	for i, layer := range lc.layers {
+		if layer.Source != OnlyNacos || layer.DataID != dataID || layer.Group != group {
+			continue
+		}
+
+		if content == "" {
+			data[i] = &layerData{}
+			continue
+		}
+
+		codec, err := codecByDataID(dataID, lc.options.Format)
+		if err != nil {
+			return nil, err
+		}
+
+		ld, err := lc.parse(layer, codec, []byte(content))
+		if err != nil {
+			return nil, err
+		}
+
+		data[i] = ld
+	}
+
+	return data, nil
+}
+
+// parse 解析文件层、nacos层的内容，WithProfile时只取当前环境的配置
+func (lc *layeredConfig) parse(layer Layer, codec Codec, raw []byte) (*layerData, error) {
+	data := raw
+	if lc.options.UseProfile {
+		block, err := selectProfile(codec, data, lc.options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+
+		data = block
+	}
+
+	var tree interface{}
+	if err := codec.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("%s: %w", layer.Name, err)
+	}
+
+	m, err := layerMap(layer, stringKeys(tree))
+	if err != nil {
+		return nil, err
+	}
+
+	return &layerData{tree: m, codec: codec, raw: raw}, nil
+}
+
+// apply 替换部分层的内容后重新合并，合并或解析失败时保留原内容
+//  返回合并前后的配置，changed表示配置是否发生了变化
+func (lc *layeredConfig) apply(data map[int]*layerData) (old, new interface{}, changed bool, err error) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	cp := make([]*layerData, len(lc.layers))
+	if state := lc.load(); state != nil {
+		copy(cp, state.data)
+	}
+
+	for i, ld := range data {
+		cp[i] = ld
+	}
+
+	value, err := lc.merge(cp)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	old = lc.get()
+	lc.state.Store(&layeredState{value: value, data: cp})
+	return old, value, !reflect.DeepEqual(old, value), nil
+}
+
+// merge 按照优先级合并各层的内容，解析为新的对象，设置默认值并校验
+func (lc *layeredConfig) merge(data []*layerData) (interface{}, error) {
+	tree := map[string]interface{}{}
+	for _, ld := range data {
+		if ld != nil && ld.tree != nil {
+			mergeTree(tree, ld.tree)
+		}
+	}
+
+	raw, err := yaml.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	// 环境已在解析各层时选择，合并后的内容不再选择
+	options := *lc.options
+	options.UseProfile = false
+
+	return decodeConfig(yamlCodec{}, raw, lc.proto, &options)
+}
+
+// close 停止监听文件层
+func (lc *layeredConfig) close(ctx context.Context) error {
+	var err error
+	for _, fw := range lc.watches {
+		if e := fw.close(ctx); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// nacosKeys 去重后的nacos层的dataID和group
+func (lc *layeredConfig) nacosKeys() [][2]string {
+	var keys [][2]string
+	seen := map[[2]string]bool{}
+	for _, layer := range lc.layers {
+		key := [2]string{layer.DataID, layer.Group}
+		if layer.Source == OnlyNacos && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// layerMap 检查层的内容是否为map，内容为空时该层为空
+func layerMap(layer Layer, tree interface{}) (map[string]interface{}, error) {
+	if tree == nil {
+		return nil, nil
+	}
+
+	m, ok := tree.(map[string]interface{})
+	if !ok {
+		return nil, mismatch(layer.Name, "map", tree)
+	}
+
+	return m, nil
+}
+
+// mergeTree 将src深度合并到dst，map逐个key合并，其他类型（包括slice）整体覆盖
+func mergeTree(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if m, ok := v.(map[string]interface{}); ok {
+			child, ok := dst[k].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				dst[k] = child
+			}
+
+			mergeTree(child, m)
+			continue
+		}
+
+		dst[k] = v
+	}
+}
+
+// pruneZero 移除树中与零值对象相同的叶子节点，zero为同类型零值对象的树，移除后为空的map也一并移除
+func pruneZero(tree, zero interface{}) interface{} {
+	if m, ok := tree.(map[string]interface{}); ok {
+		zm, _ := zero.(map[string]interface{})
+		pruned := map[string]interface{}{}
+		for k, v := range m {
+			if v = pruneZero(v, zm[k]); v != nil {
+				pruned[k] = v
+			}
+		}
+
+		if len(pruned) == 0 {
+			return nil
+		}
+
+		return pruned
+	}
+
+	if reflect.DeepEqual(tree, zero) {
+		return nil
+	}
+
+	return tree
+}
+
+// setPath 在tree中按照路径设置值，中间节点不存在或不是map时创建
+func setPath(tree map[string]interface{}, path []string, v interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := tree[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			tree[key] = child
+		}
+
+		tree = child
+	}
+
+	tree[path[len(path)-1]] = v
+}
+
+// envLeaf 可以由环境变量设置的字段
+type envLeaf struct {
+	name string       // 环境变量名称
+	path []string     // yaml路径
+	t    reflect.Type // 字段类型
+}
+
+// envLeaves 根据t的字段生成 yaml路径 -> 环境变量，规则与applyEnv一致，不包含map中的key
+func envLeaves(t reflect.Type, prefix string) map[string]envLeaf {
+	leaves := map[string]envLeaf{}
+	walkEnvType(t, strings.ToUpper(prefix), nil, leaves)
+	return leaves
+}
+
+// walkEnvType 遍历类型，name为当前类型对应的环境变量名称前缀，为空时只处理env tag
+func walkEnvType(t reflect.Type, name string, path []string, leaves map[string]envLeaf) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || isScalar(t) {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, inline := yamlKey(field)
+		if key == "" && !inline {
+			continue
+		}
+
+		child, childPath := name, path
+		if !inline {
+			child, childPath = "", append(path[:len(path):len(path)], key)
+			if name != "" {
+				child = envName(name, key)
+			}
+		}
+
+		if tag, ok := field.Tag.Lookup("env"); ok {
+			if tag == "-" {
+				continue
+			}
+			child = tag
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if !isScalar(ft) && !(ft.Kind() == reflect.Slice && isScalar(ft.Elem())) {
+			walkEnvType(ft, child, childPath, leaves)
+			continue
+		}
+
+		if child != "" {
+			leaves[strings.Join(childPath, ".")] = envLeaf{name: child, path: childPath, t: ft}
+		}
+	}
+}
+
+// pathType 获取t中yaml路径对应字段的类型，无法确定时返回nil
+func pathType(t reflect.Type, path []string) reflect.Type {
+	for _, key := range path {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch {
+		case t == nil:
+			return nil
+		case t.Kind() == reflect.Map:
+			t = t.Elem()
+		case t.Kind() == reflect.Struct:
+			field, ok := structField(t, key)
+			if !ok {
+				return nil
+			}
+
+			t = field.Type
+		default:
+			return nil
+		}
+	}
+
+	return t
+}
+
+// structField 获取struct中yaml key对应的字段，包括内联的字段
+func structField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		k, inline := yamlKey(field)
+		if k == key {
+			return field, true
+		}
+
+		if inline {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				if inner, ok := structField(ft, key); ok {
+					return inner, true
+				}
+			}
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// leafValue 将环境变量、命令行参数的字符串转换为层中的值
+//  字符串类型的字段保持原值；slice按照","分割；其他按照yaml规则识别数字、bool等标量类型
+func leafValue(t reflect.Type, raw string) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return parseScalar(raw)
+	}
+
+	if t.Kind() == reflect.String {
+		return raw
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		items := []interface{}{}
+		if raw == "" {
+			return items
+		}
+
+		for _, item := range strings.Split(raw, ",") {
+			items = append(items, leafValue(t.Elem(), strings.TrimSpace(item)))
+		}
+
+		return items
+	}
+
+	return parseScalar(raw)
+}
+
+// RegisterLayered 注册分层配置
+func (c *configIns) RegisterLayered(v interface{}, layers []Layer, opts ...RegisterOption) error {
+	return c.RegisterLayeredWithName(defaultName, v, layers, opts...)
+}
+
+// RegisterLayeredWithName 注册分层配置，layers按照优先级从低到高排列，e.g.
+//  DefaultsLayer -> FileLayer -> OptionalFileLayer -> NacosLayer -> EnvLayer -> FlagsLayer
+//  各层深度合并后解析为v的类型，设置默认值并校验；文件层（需指定WithWatch）或nacos层变化时重新合并
+//  WithProfile作用于文件层和nacos层；包含nacos层时需要先调用DailNacos
+func (c *configIns) RegisterLayeredWithName(name string, v interface{}, layers []Layer, opts ...RegisterOption) error {
+	if err := checkType(v); err != nil {
+		return err
+	}
+
+	if len(layers) == 0 {
+		return ErrEmptyLayers
+	}
+
+	s := c.load()
+	if err := s.checkLayered(name); err != nil {
+		return err
+	}
+
+	options := NewRegisterOptions(opts...)
+	lc := newLayeredConfig(v, layers, options)
+
+	data := map[int]*layerData{}
+	for i, layer := range layers {
+		if layer.Source == OnlyNacos {
+			continue
+		}
+
+		ld, err := lc.read(i)
+		if err != nil {
+			return err
+		}
+
+		data[i] = ld
+	}
+
+	// 从nacos拉取各nacos层的内容，服务端不可用时使用本地快照；拉取时不持有regMutex
+	keys := lc.nacosKeys()
+	contents := make([]string, len(keys))
+	saves := make([]*savedConfig, len(keys))
+	for i, key := range keys {
+		dataID, group := key[0], key[1]
+		if _, err := s.checkNacos(false, dataID, group, options); err != nil {
+			return err
+		}
+
+		content, err := c.fetch(s.client, dataID, group)
+		if saves[i], err = c.fallbackSnapshot(name, Layered, s.namespace, dataID, group, err); err != nil {
+			return err
+		}
+
+		if saves[i] != nil {
+			content = saves[i].Content
+		}
+
+		nacosData, err := lc.nacosData(dataID, group, content)
+		if err != nil {
+			return err
+		}
+
+		for j, ld := range nacosData {
+			data[j] = ld
+		}
+
+		contents[i] = content
+	}
+
+	if _, _, _, err := lc.apply(data); err != nil {
+		return err
+	}
+
+	c.regMutex.Lock()
+	defer c.regMutex.Unlock()
+
+	// 拉取期间可能已关闭，或注册了同名的配置、相同的dataID和group
+	s = c.load()
+	if err := s.checkLayered(name); err != nil {
+		return err
+	}
+
+	listened := make([]bool, len(keys))
+	for i, key := range keys {
+		var err error
+		if listened[i], err = s.checkNacos(false, key[0], key[1], options); err != nil {
+			return err
+		}
+	}
+
+	// 与registerNacos一致，在监听之前标记使用了本地快照
+	for i, key := range keys {
+		if saves[i] != nil {
+			c.markStale(s.namespace, key[0], key[1], saves[i].UpdatedAt)
+		}
+	}
+
+	if options.Watch {
+		if err := c.watchLayers(name, lc); err != nil {
+			lc.close(context.Background())
+			return err
+		}
+	}
+
+	// 监听失败时取消本次已开始的监听，避免残留没有配置使用的监听
+	var started []*listener
+	for i, key := range keys {
+		if listened[i] {
+			continue
+		}
+
+		if err := c.listen(s.client, key[0], key[1]); err != nil {
+			for _, l := range started {
+				cancelListen(s.client, l)
+			}
+
+			lc.close(context.Background())
+			return err
+		}
+
+		started = append(started, &listener{namespace: s.namespace, dataID: key[0], group: key[1]})
+	}
+
+	c.commit(func(s *snapshot) {
+		s.layered[name] = lc
+		for _, key := range keys {
+			s.addListener(key[0], key[1], name, Layered)
+		}
+	})
+
+	for i, key := range keys {
+		if saves[i] == nil && contents[i] != "" {
+			c.synced(name, Layered, s.namespace, key[0], key[1], contents[i])
+		}
+	}
+
+	return nil
+}
+
+// checkLayered 检查是否可以注册分层配置
+func (s *snapshot) checkLayered(name string) error {
+	if s.closed {
+		return ErrClosed
+	}
+
+	if _, exist := s.layered[name]; exist {
+		return ErrAlreadyRegister
+	}
+
+	return nil
+}
+
+// watchLayers 监听所有文件层，文件变化后重新合并
+func (c *configIns) watchLayers(name string, lc *layeredConfig) error {
+	for i, layer := range lc.layers {
+		if layer.Source != OnlyFile {
+			continue
+		}
+
+		i := i
+		fw, err := watchFile(layer.File, func() {
+			ld, err := lc.read(i)
+			if err != nil {
+				c.failUpdate(name, Layered, "", err)
+				return
+			}
+
+			c.applyLayers(name, lc, map[int]*layerData{i: ld}, string(ld.raw))
+		}, func(err error) {
+			c.failUpdate(name, Layered, "", err)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		lc.watches = append(lc.watches, fw)
+	}
+
+	return nil
+}
+
+// applyLayers 替换部分层的内容后重新合并，配置变化时通知订阅者，失败时记录payload并通过OnError通知
+func (c *configIns) applyLayers(name string, lc *layeredConfig, data map[int]*layerData, payload string) bool {
+	old, new, changed, err := lc.apply(data)
+	if err != nil {
+		c.failUpdate(name, Layered, payload, err)
+		return false
+	}
+
+	if changed {
+		c.notify(name, old, new)
+	}
+
+	return true
+}
+
+// updateLayered nacos层变化时重新合并，返回是否更新成功
+func (c *configIns) updateLayered(name string, lc *layeredConfig, dataID, group, data string) bool {
+	ld, err := lc.nacosData(dataID, group, data)
+	if err != nil {
+		c.failUpdate(name, Layered, data, err)
+		return false
+	}
+
+	return c.applyLayers(name, lc, ld, data)
+}
+
+// GetLayeredConfig 获取分层模式的配置信息
+func (c *configIns) GetLayeredConfig() interface{} {
+	return c.GetLayeredConfigByName(defaultName)
+}
+
+// GetLayeredConfigByName 获取分层模式下指定名称的配置信息
+//  返回的配置发布后不再修改，外部禁止修改；需要修改时注册时指定WithDeepCopy()
+func (c *configIns) GetLayeredConfigByName(name string) interface{} {
+	if lc, exist := c.load().layered[name]; exist {
+		return readValue(lc.get(), lc.options)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// listenFailClient 监听指定dataID时失败的nacos客户端
+type listenFailClient struct {
+	*configtest.Client
+	dataID string
+}
+
+func (lc *listenFailClient) ListenConfig(param vo.ConfigParam) error {
+	if param.DataId == lc.dataID {
+		return errors.New("listen failed")
+	}
+
+	return lc.Client.ListenConfig(param)
+}
+
+type LayeredConf struct {
+	Name    string        `yaml:"name" validate:"required"`
+	Version string        `yaml:"version"`
+	Timeout time.Duration `yaml:"timeout" default:"1s"`
+	Hosts   []string      `yaml:"hosts"`
+	Redis   RedisConf     `yaml:"redis"`
+}
+
+var _ = Describe("Layered", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "layered")
+		Expect(err).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).Should(Succeed())
+		return file
+	}
+
+	It("merge by precedence", func() {
+		defer os.Unsetenv("LAYER_REDIS_PORT")
+		defer os.Unsetenv("LAYER_VERSION")
+		os.Setenv("LAYER_REDIS_PORT", "6380")
+		os.Setenv("LAYER_VERSION", "007")
+
+		base := writeFile("app.yaml", "name: app\nhosts: [a, b]\nredis:\n  host: base\n  port: 6379\n  max_idle: 5")
+		prod := writeFile("app.prod.yaml", "redis:\n  host: prod")
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("redis.user_name", "", "")
+		fs.String("redis.password", "", "")
+		Expect(fs.Parse([]string{"-redis.user_name=admin"})).Should(Succeed())
+
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "hosts: [c]\nredis:\n  max_idle: 10"})
+
+		err := c.RegisterLayered(&LayeredConf{}, []primitive.Layer{
+			primitive.DefaultsLayer(LayeredConf{Redis: RedisConf{MaxActive: 3}}),
+			primitive.FileLayer(base),
+			primitive.OptionalFileLayer(prod),
+			primitive.OptionalFileLayer(filepath.Join(dir, "app.test.yaml")),
+			primitive.NacosLayer(dataID, group),
+			primitive.EnvLayer("layer"),
+			primitive.FlagsLayer(fs),
+		})
+		Expect(err).Should(Succeed())
+
+		v, flag := c.GetConfigWithFlag()
+		Expect(flag == primitive.Layered).Should(BeTrue())
+
+		conf := v.(*LayeredConf)
+		Expect(conf.Name == "app").Should(BeTrue())
+		Expect(conf.Version == "007").Should(BeTrue())
+		Expect(conf.Timeout == time.Second).Should(BeTrue())
+		Expect(conf.Hosts).Should(Equal([]string{"c"}))
+		Expect(conf.Redis.Host == "prod").Should(BeTrue())
+		Expect(conf.Redis.Port == 6380).Should(BeTrue())
+		Expect(conf.Redis.MaxIdle == 10).Should(BeTrue())
+		Expect(conf.Redis.MaxActive == 3).Should(BeTrue())
+		Expect(conf.Redis.UserName == "admin").Should(BeTrue())
+
+		sources := map[string]string{}
+		for _, path := range []string{"name", "hosts", "redis.host", "redis.port", "redis.user_name", "redis.max_active"} {
+			e, err := c.Explain(defaultName, path)
+			Expect(err).Should(Succeed())
+			sources[path] = e.Origin.Layer
+		}
+
+		Expect(sources["name"] == "file:"+base).Should(BeTrue())
+		Expect(sources["hosts"] == "nacos:"+group+"/"+dataID).Should(BeTrue())
+		Expect(sources["redis.host"] == "file:"+prod).Should(BeTrue())
+		Expect(sources["redis.port"] == "env").Should(BeTrue())
+		Expect(sources["redis.user_name"] == "flags").Should(BeTrue())
+		Expect(sources["redis.max_active"] == "defaults").Should(BeTrue())
+
+		port, err := c.GetInt("redis.port")
+		Expect(err).Should(Succeed())
+		Expect(port == 6380).Should(BeTrue())
+	})
+
+	It("remerge on nacos change", func() {
+		base := writeFile("app.yaml", "name: app\nredis:\n  host: base")
+
+		c, client := newTestIns()
+		Expect(c.RegisterLayeredWithName("app", &LayeredConf{}, []primitive.Layer{
+			primitive.FileLayer(base),
+			primitive.NacosLayer(dataID, group),
+		})).Should(Succeed())
+		Expect(c.GetConfigByName("app").(*LayeredConf).Redis.Host == "base").Should(BeTrue())
+
+		var changes []primitive.Change
+		c.Subscribe("app", func(old, new interface{}) {
+			changes = append(changes, primitive.Change{Old: old, New: new})
+		})
+
+		var reported error
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			Expect(source == primitive.Layered).Should(BeTrue())
+			reported = err
+		})
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "redis:\n  host: nacos"})
+		Expect(c.GetConfigByName("app").(*LayeredConf).Redis.Host == "nacos").Should(BeTrue())
+		Expect(c.GetConfigByName("app").(*LayeredConf).Name == "app").Should(BeTrue())
+		Expect(len(changes) == 1).Should(BeTrue())
+		Expect(changes[0].Old.(*LayeredConf).Redis.Host == "base").Should(BeTrue())
+
+		// 非法内容保留原配置
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "redis: ["})
+		Expect(reported).ShouldNot(BeNil())
+		Expect(c.GetConfigByName("app").(*LayeredConf).Redis.Host == "nacos").Should(BeTrue())
+
+		// 删除后使用低优先级的值
+		client.DeleteConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(c.GetConfigByName("app").(*LayeredConf).Redis.Host == "base").Should(BeTrue())
+		Expect(len(changes) == 2).Should(BeTrue())
+
+		Expect(c.Unregister("app")).Should(Succeed())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+	})
+
+	It("listen failed", func() {
+		client := &listenFailClient{Client: configtest.NewClient(), dataID: "override.yaml"}
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "name: app"})
+		client.PublishConfig(vo.ConfigParam{DataId: "override.yaml", Group: group, Content: "version: v2"})
+
+		// 第二层监听失败时取消第一层的监听
+		c := NewConfigIns(primitive.WithNacosClient(client, ""))
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{
+			primitive.NacosLayer(dataID, group),
+			primitive.NacosLayer("override.yaml", group),
+		})).ShouldNot(Succeed())
+		Expect(client.Listening(dataID, group) == 0).Should(BeTrue())
+		Expect(c.load().layered).Should(BeEmpty())
+		Expect(c.load().listeners).Should(BeEmpty())
+	})
+
+	It("remerge on file change", func() {
+		base := writeFile("app.yaml", "name: app\nredis:\n  host: base")
+
+		c := NewConfigIns()
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{
+			primitive.FileLayer(base),
+		}, primitive.WithWatch())).Should(Succeed())
+
+		writeFile("app.yaml", "name: app\nredis:\n  host: changed")
+		Eventually(func() string {
+			return c.GetLayeredConfig().(*LayeredConf).Redis.Host
+		}, time.Second).Should(Equal("changed"))
+
+		Expect(c.Close(context.Background())).Should(Succeed())
+		Expect(c.load().layered[defaultName].watches[0].done).Should(BeClosed())
+	})
+
+	It("profile", func() {
+		base := writeFile("app.yaml", "cur_env: local\nenvs:\n  local:\n    name: local\n  prod:\n    name: prod")
+
+		c := NewConfigIns()
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{
+			primitive.DefaultsLayer(&LayeredConf{Version: "v1"}),
+			primitive.FileLayer(base),
+		}, primitive.WithProfile("prod"))).Should(Succeed())
+
+		conf := c.GetLayeredConfig().(*LayeredConf)
+		Expect(conf.Name == "prod").Should(BeTrue())
+		Expect(conf.Version == "v1").Should(BeTrue())
+	})
+
+	It("invalid", func() {
+		c := NewConfigIns()
+		Expect(c.RegisterLayered(&LayeredConf{}, nil)).Should(Equal(primitive.ErrEmptyLayers))
+		Expect(c.RegisterLayered(LayeredConf{}, []primitive.Layer{primitive.EnvLayer("")})).Should(Equal(primitive.ErrMustBePointer))
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{primitive.FileLayer(filepath.Join(dir, "none.yaml"))})).ShouldNot(Succeed())
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{primitive.NacosLayer(dataID, group)})).Should(Equal(primitive.ErrDialNacosFirst))
+
+		// 校验失败
+		Expect(c.RegisterLayered(&LayeredConf{}, []primitive.Layer{primitive.DefaultsLayer(LayeredConf{Version: "v1"})})).ShouldNot(Succeed())
+	})
+})
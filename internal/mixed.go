@@ -0,0 +1,193 @@
+package internal
+
+import (
+	. "config/primitive"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v2"
+)
+
+type mixedConfig struct {
+	mutex sync.Mutex // 保证更新操作串行执行，保护content
+
+	dataID  string
+	group   string
+	file    string           // 配置文件
+	codec   Codec            // 配置文件的编解码器
+	data    []byte           // 注册时的配置文件内容
+	options *RegisterOptions // 注册选项
+	proto   interface{}      // 注册时的对象，用于生成新的配置对象
+	base    interface{}      // 配置文件解析的配置，内置合并时的基础，不再修改
+	value   atomic.Value     // 当前生效的配置，发布后不再修改
+	ready   *readiness       // 是否已从nacos获取到内容
+	content string           // 最近一次更新成功的nacos内容
+}
+
+// get 获取当前生效的配置
+func (mc *mixedConfig) get() interface{} {
+	return mc.value.Load()
+}
+
+// update nacos配置变化时更新配置，环境变量的优先级高于nacos配置，更新后校验配置
+//  配置实现了OnNacosChanged时在当前配置的副本上调用，否则按照合并方式生成新的配置，
+//  成功后替换，读取方不会看到更新了一半的配置
+func (mc *mixedConfig) update(namespace, group, dataID, data string) (old, new interface{}, err error) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	old = mc.get()
+	if _, ok := old.(NacosChangedHook); ok {
+		new, err = mc.onChanged(old, namespace, group, dataID, data)
+	} else {
+		new, err = mc.merge(data)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mc.value.Store(new)
+	mc.content = data
+	return old, new, nil
+}
+
+// onChanged 在当前配置的副本上调用OnNacosChanged
+func (mc *mixedConfig) onChanged(cur interface{}, namespace, group, dataID, data string) (interface{}, error) {
+	cp, ok := deepCopy(cur).(NacosChangedHook)
+	if !ok {
+		return nil, ErrCopyException
+	}
+
+	if err := cp.OnNacosChanged(namespace, group, dataID, data); err != nil {
+		return nil, err
+	}
+
+	if mc.options.Env {
+		if err := applyEnv(cp, mc.options.EnvPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validate(cp); err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// merge 按照合并方式将nacos内容合并到配置文件的配置上，nacos内容为空时使用配置文件的配置
+//  WithProfile时nacos内容同样只使用当前环境的配置
+func (mc *mixedConfig) merge(data string) (interface{}, error) {
+	if data == "" {
+		return deepCopy(mc.base), nil
+	}
+
+	codec, err := codecByDataID(mc.dataID, mc.options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := []byte(data)
+	if mc.options.UseProfile {
+		if raw, err = selectProfile(codec, raw, mc.options); err != nil {
+			return nil, err
+		}
+	}
+
+	var tree interface{}
+	if err = codec.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+
+	src, ok := stringKeys(tree).(map[string]interface{})
+	if !ok {
+		return nil, mismatch(mc.group+"/"+mc.dataID, "map", tree)
+	}
+
+	dst := src
+	if mc.options.Merge != MergeReplace {
+		base, err := toTree(mc.base)
+		if err != nil {
+			return nil, err
+		}
+
+		dst, _ = base.(map[string]interface{})
+		if dst == nil {
+			dst = map[string]interface{}{}
+		}
+
+		if mc.options.Merge == MergeField {
+			mergeFields(dst, src, reflect.TypeOf(mc.proto))
+		} else {
+			mergeTree(dst, src)
+		}
+	}
+
+	merged, err := yaml.Marshal(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	// 环境已在解析时选择，合并后的内容不再选择
+	options := *mc.options
+	options.UseProfile = false
+
+	return decodeConfig(yamlCodec{}, merged, mc.proto, &options)
+}
+
+// mergeFields 按照t中字段的merge tag将src合并到dst
+//  overlay（默认）：map逐个key合并；replace：整体替换；ignore：保留dst的值
+func mergeFields(dst, src map[string]interface{}, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for k, v := range src {
+		var (
+			ft   reflect.Type
+			mode string
+		)
+
+		switch {
+		case t == nil:
+		case t.Kind() == reflect.Struct:
+			if field, ok := structField(t, k); ok {
+				ft, mode = field.Type, field.Tag.Get("merge")
+			}
+		case t.Kind() == reflect.Map:
+			ft = t.Elem()
+		}
+
+		switch mode {
+		case "ignore":
+			continue
+		case "replace":
+			dst[k] = v
+			continue
+		}
+
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		child, ok := dst[k].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			dst[k] = child
+		}
+
+		mergeFields(child, m, ft)
+	}
+}
+
+// nacosContent 最近一次更新成功的nacos内容
+func (mc *mixedConfig) nacosContent() string {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	return mc.content
+}
@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type MergeConf struct {
+	Name   string            `yaml:"name"`
+	Port   int               `yaml:"port" default:"8080"`
+	Labels map[string]string `yaml:"labels"`
+	Tags   map[string]string `yaml:"tags" merge:"replace"`
+	Secret string            `yaml:"secret" merge:"ignore"`
+	Redis  RedisConf         `yaml:"redis"`
+}
+
+type AfterRegisterConf struct {
+	MergeConf  `yaml:",inline"`
+	Registered bool `yaml:"-"`
+}
+
+func (ac *AfterRegisterConf) UpdateAfterRegister() {
+	ac.Registered = true
+}
+
+const mergeFile = `name: file
+labels:
+  a: file
+  b: file
+tags:
+  x: file
+  y: file
+secret: file
+redis:
+  host: file
+  port: 6379
+`
+
+const mergeContent = `name: nacos
+labels:
+  a: nacos
+tags:
+  x: nacos
+secret: nacos
+redis:
+  host: nacos
+`
+
+var _ = Describe("Mixed", func() {
+	var (
+		dir  string
+		file string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "mixed")
+		Expect(err).Should(Succeed())
+
+		file = filepath.Join(dir, "app.yaml")
+		Expect(ioutil.WriteFile(file, []byte(mergeFile), 0644)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newIns := func(content string) (*configIns, func(string)) {
+		c, client := newTestIns()
+		publish := func(content string) {
+			if content == "" {
+				client.DeleteConfig(vo.ConfigParam{DataId: dataID, Group: group})
+				return
+			}
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: content})
+		}
+
+		publish(content)
+		return c, publish
+	}
+
+	It("overlay", func() {
+		c, publish := newIns(mergeContent)
+		Expect(c.RegisterMixed(file, dataID, group, &MergeConf{})).Should(Succeed())
+
+		conf := c.GetMixedConfig().(*MergeConf)
+		Expect(conf.Name == "nacos").Should(BeTrue())
+		Expect(conf.Port == 8080).Should(BeTrue())
+		Expect(conf.Labels["a"] == "nacos" && conf.Labels["b"] == "file").Should(BeTrue())
+		Expect(conf.Tags["x"] == "nacos" && conf.Tags["y"] == "file").Should(BeTrue())
+		Expect(conf.Secret == "nacos").Should(BeTrue())
+		Expect(conf.Redis.Host == "nacos" && conf.Redis.Port == 6379).Should(BeTrue())
+
+		// 每次变更都基于配置文件合并，不会保留上一次nacos的内容
+		publish("redis:\n  port: 6380")
+		conf = c.GetMixedConfig().(*MergeConf)
+		Expect(conf.Name == "file").Should(BeTrue())
+		Expect(conf.Redis.Host == "file" && conf.Redis.Port == 6380).Should(BeTrue())
+
+		// nacos内容删除后恢复为配置文件的内容
+		publish("")
+		conf = c.GetMixedConfig().(*MergeConf)
+		Expect(conf.Name == "file" && conf.Redis.Port == 6379).Should(BeTrue())
+	})
+
+	It("replace", func() {
+		c, _ := newIns(mergeContent)
+		Expect(c.RegisterMixed(file, dataID, group, &MergeConf{}, primitive.WithMerge(primitive.MergeReplace))).Should(Succeed())
+
+		conf := c.GetMixedConfig().(*MergeConf)
+		Expect(conf.Name == "nacos").Should(BeTrue())
+		Expect(conf.Port == 8080).Should(BeTrue())
+		Expect(len(conf.Labels) == 1 && len(conf.Tags) == 1).Should(BeTrue())
+		Expect(conf.Redis.Host == "nacos" && conf.Redis.Port == 0).Should(BeTrue())
+	})
+
+	It("field", func() {
+		c, _ := newIns(mergeContent)
+		Expect(c.RegisterMixed(file, dataID, group, &MergeConf{}, primitive.WithMerge(primitive.MergeField))).Should(Succeed())
+
+		conf := c.GetMixedConfig().(*MergeConf)
+		Expect(conf.Name == "nacos").Should(BeTrue())
+		Expect(conf.Labels["a"] == "nacos" && conf.Labels["b"] == "file").Should(BeTrue())
+		Expect(len(conf.Tags) == 1 && conf.Tags["x"] == "nacos").Should(BeTrue())
+		Expect(conf.Secret == "file").Should(BeTrue())
+		Expect(conf.Redis.Host == "nacos" && conf.Redis.Port == 6379).Should(BeTrue())
+	})
+
+	It("hooks", func() {
+		c, publish := newIns(mergeContent)
+		Expect(c.RegisterMixed(file, dataID, group, &AfterRegisterConf{})).Should(Succeed())
+
+		conf := c.GetMixedConfig().(*AfterRegisterConf)
+		Expect(conf.Registered).Should(BeTrue())
+		Expect(conf.Name == "nacos" && conf.Labels["b"] == "file").Should(BeTrue())
+
+		// UpdateAfterRegister只在注册时调用
+		publish("name: changed")
+		conf = c.GetMixedConfig().(*AfterRegisterConf)
+		Expect(conf.Name == "changed" && !conf.Registered).Should(BeTrue())
+
+		// 实现OnNacosChanged时由其解析nacos内容，不使用内置的合并
+		c, _ = newMixedIns()
+		Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+		mongo := c.GetMixedConfig().(*MongoConf)
+		Expect(mongo.MinPoolSize == 1024 && mongo.MaxPoolSize == 150 && mongo.DB == "").Should(BeTrue())
+	})
+
+	It("invalid content", func() {
+		c, _ := newIns("- a\n- b")
+		err := c.RegisterMixed(file, dataID, group, &MergeConf{})
+		Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+	})
+})
@@ -2,12 +2,17 @@ package internal
 
 import (
 	. "config/primitive"
+	"errors"
 	"fmt"
+	"net"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/nacos-group/nacos-sdk-go/clients"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/common/nacos_error"
 	"github.com/nacos-group/nacos-sdk-go/vo"
 )
 
@@ -17,36 +22,180 @@ const (
 	defaultLogLevel = "debug"
 )
 
-var registeredDataIDAndGroup = map[string]struct{}{}
-
 type nacosConfig struct {
-	mutex sync.RWMutex
+	mutex sync.Mutex // 保证更新操作串行执行，nacos客户端在不同的协程中触发监听
 
 	dataID  string
 	group   string
+	proto   interface{}      // 不为nil时，配置内容按照proto的类型解析
+	codec   Codec            // 配置内容的编解码器
+	verify  bool             // proto为nil时按照codec校验原始内容，dataID有可识别的扩展名或指定了格式
+	options *RegisterOptions // 注册选项
+	state   atomic.Value     // *nacosState，当前生效的配置，读取时无锁
+	ready   *readiness       // 是否已从nacos获取到内容
+}
+
+type nacosState struct {
 	content string
+	value   interface{} // 配置内容解析后的对象
+}
+
+// update 更新配置内容，返回更新前后的配置；proto不为nil时解析为新的对象，否则按照格式校验原始内容，失败则保留原配置
+func (nc *nacosConfig) update(content string) (old, new interface{}, err error) {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	var value interface{}
+	if nc.proto == nil && nc.verify && content != "" {
+		var tree interface{}
+		if err := nc.codec.Unmarshal([]byte(content), &tree); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if nc.proto != nil {
+		v, err := decodeConfig(nc.codec, []byte(content), nc.proto, nc.options)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value = v
+	}
+
+	old = nc.get()
+	nc.state.Store(&nacosState{content: content, value: value})
+	return old, nc.get(), nil
+}
+
+// fallback 懒注册时设置初始值，v为nil时使用只设置了默认值的对象
+func (nc *nacosConfig) fallback(v interface{}) error {
+	if nc.proto == nil {
+		content, _ := v.(string)
+		nc.state.Store(&nacosState{content: content})
+		return nil
+	}
+
+	if v == nil {
+		empty := reflect.New(reflect.Indirect(reflect.ValueOf(nc.proto)).Type())
+		if err := applyDefaults(empty); err != nil {
+			return err
+		}
+
+		v = empty.Interface()
+	} else if reflect.TypeOf(v) != reflect.TypeOf(nc.proto) {
+		return ErrTypeMismatch
+	}
+
+	nc.state.Store(&nacosState{value: v})
+	return nil
+}
+
+// content 获取当前生效的原始内容，懒注册尚未获取到内容时为空
+func (nc *nacosConfig) content() string {
+	if state, _ := nc.state.Load().(*nacosState); state != nil {
+		return state.content
+	}
+
+	return ""
 }
 
-// isRegistered 检查dataID和group是否已经注册过
-func isRegistered(dataID, group string) bool {
-	_, exist := registeredDataIDAndGroup[fmt.Sprintf("%s:%s", dataID, group)]
-	return exist
+// get 获取配置；proto不为nil时返回解析后的对象，否则返回原始内容
+func (nc *nacosConfig) get() interface{} {
+	state, _ := nc.state.Load().(*nacosState)
+	if state == nil {
+		return nil
+	}
+
+	if nc.proto != nil {
+		return state.value
+	}
+
+	return state.content
+}
+
+// listener nacos配置监听，同一namespace下的dataID和group只监听一次，可由多个配置共享
+//  快照中的listener不可修改，变更时复制
+type listener struct {
+	namespace string
+	dataID    string
+	group     string
+
+	nacos   []string // 共享该监听的nacos配置名称
+	mixed   []string // 共享该监听的混合配置名称
+	layered []string // 共享该监听的分层配置名称
+}
+
+// refs 共享该监听的配置数量
+func (l *listener) refs() int {
+	return len(l.nacos) + len(l.mixed) + len(l.layered)
+}
+
+// remove 移除监听该dataID和group的配置名称，返回新的listener
+func (l *listener) remove(name string, flag Flag) *listener {
+	cp := &listener{namespace: l.namespace, dataID: l.dataID, group: l.group}
+
+	for _, n := range l.nacos {
+		if flag != OnlyNacos || n != name {
+			cp.nacos = append(cp.nacos, n)
+		}
+	}
+
+	for _, n := range l.mixed {
+		if flag != Mixed || n != name {
+			cp.mixed = append(cp.mixed, n)
+		}
+	}
+
+	for _, n := range l.layered {
+		if flag != Layered || n != name {
+			cp.layered = append(cp.layered, n)
+		}
+	}
+
+	return cp
 }
 
-// markRegistered 标记dataID和group已经注册过
-func markRegistered(dataID, group string) {
-	registeredDataIDAndGroup[fmt.Sprintf("%s:%s", dataID, group)] = struct{}{}
+// listenKey 监听的唯一标识
+func listenKey(namespace, dataID, group string) string {
+	return fmt.Sprintf("%s:%s:%s", namespace, dataID, group)
 }
 
-// clearRegistered 清理已经注册过的dataID和group
-func clearRegistered() {
-	registeredDataIDAndGroup = map[string]struct{}{}
+// clientOptions 根据实例选项生成创建nacos客户端的选项
+func clientOptions(o *ConfigOptions) []ClientOption {
+	var opts []ClientOption
+	if o.LogDir != "" {
+		opts = append(opts, constant.WithLogDir(o.LogDir))
+	}
+
+	if o.CacheDir != "" {
+		opts = append(opts, constant.WithCacheDir(o.CacheDir))
+	}
+
+	if o.Logger != nil {
+		opts = append(opts, constant.WithCustomLogger(o.Logger))
+	}
+
+	return append(opts, o.ClientOptions...)
 }
 
-// NewNacosClient 创建Nacos客户端
+// NewNacosClient 创建Nacos客户端，创建前解析地址中的域名
 //  e.g
 //  c := NewNacosClient("localhost:8080", namespace, WithAccessKey("accessKey"), WithSecretKey("secretKey"))
 func NewNacosClient(addr, namespace string, opts ...ClientOption) (INacosClient, error) {
+	if err := resolve(addr); err != nil {
+		return nil, err
+	}
+
+	return newNacosClient(addr, namespace, opts...)
+}
+
+// newNacosClient 创建Nacos客户端，不访问服务端
+func newNacosClient(addr, namespace string, opts ...ClientOption) (INacosClient, error) {
+	return clients.NewConfigClient(vo.NacosClientParam{ClientConfig: clientConfig(addr, namespace, opts...)})
+}
+
+// clientConfig 默认的客户端配置，opts优先；日志默认不输出到标准输出，可通过WithLogStdout(true)开启
+func clientConfig(addr, namespace string, opts ...ClientOption) *constant.ClientConfig {
 	cc := constant.NewClientConfig(
 		constant.WithEndpoint(addr),
 		constant.WithNamespaceId(namespace),
@@ -54,29 +203,73 @@ func NewNacosClient(addr, namespace string, opts ...ClientOption) (INacosClient,
 		constant.WithLogDir(defaultLogDir),
 		constant.WithCacheDir(defaultCacheDir), // 断网情况下，GetConfig()会读取缓存数据
 		constant.WithLogLevel(defaultLogLevel),
-		constant.WithLogStdout(true),
 	)
 
 	for _, opt := range opts {
 		opt(cc)
 	}
 
-	client, err := clients.NewConfigClient(vo.NacosClientParam{ClientConfig: cc})
-	if err != nil {
-		return nil, err
+	return cc
+}
+
+// resolve 解析nacos地址中的域名，失败时返回ErrDNS
+func resolve(addr string) error {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	if host == "" || net.ParseIP(host) != nil {
+		return nil
+	}
+
+	if _, err := net.LookupHost(host); err != nil {
+		return connectError(err)
 	}
 
-	// 用于nacos sdk不支持ping操作，这个只能通过PublishConfig发起请求，如果返回的err中
-	// 包含"server list is empty"，表示无法登录nacos server
-	_, err = client.PublishConfig(vo.ConfigParam{
-		DataId:  "echo",
-		Group:   "echo",
-		Content: "ping",
+	return nil
+}
+
+// probe 探测nacos服务端是否可用
+//  通过SearchConfig查询dataID和group，不修改服务端数据，只需要读权限；
+//  GetConfig失败时会读取本地缓存，无法反映服务端的状态，因此不使用GetConfig
+func probe(client INacosClient, dataID, group string) error {
+	_, err := client.SearchConfig(vo.SearchConfigParam{
+		Search:   "accurate",
+		DataId:   dataID,
+		Group:    group,
+		PageNo:   1,
+		PageSize: 1,
 	})
 
-	if err != nil && strings.Contains(err.Error(), "server list is empty") {
-		return nil, ErrConnectFailed
+	return connectError(err)
+}
+
+// connectError 将nacos客户端返回的错误转换为*ConnectError，区分鉴权、网络、域名解析失败
+//  nacos-sdk-go v1.1.4未导出服务端地址为空、鉴权失败的错误类型，只能按照错误信息匹配，升级SDK时需要确认；
+//  无法识别的错误Kind为nil，不重试
+func connectError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		dnsErr   *net.DNSError
+		netErr   net.Error
+		nacosErr *nacos_error.NacosError
+	)
+
+	ce := &ConnectError{Err: err}
+	switch {
+	case errors.As(err, &dnsErr):
+		ce.Kind = ErrDNS
+	case errors.As(err, &netErr), strings.Contains(err.Error(), "server list is empty"): // 所有服务端地址都不可用
+		ce.Kind = ErrNetwork
+	case strings.Contains(err.Error(), "forbidden"): // 服务端返回403时的错误信息
+		ce.Kind = ErrAuthFailed
+	case errors.As(err, &nacosErr) && (nacosErr.ErrorCode() == "401" || nacosErr.ErrorCode() == "403"):
+		ce.Kind = ErrAuthFailed
 	}
 
-	return client, nil
+	return ce
 }
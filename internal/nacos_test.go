@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"context"
+
+	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newTestIns 创建使用内存nacos客户端的实例
+func newTestIns() (*configIns, *configtest.Client) {
+	client := configtest.NewClient()
+	return NewConfigIns(primitive.WithNacosClient(client, client.Namespace())), client
+}
+
+var _ = Describe("Nacos", func() {
+	const mongoContent = "host: mongodb://server:27017/monkey\nmax_pool_size: 150"
+
+	Context("struct", func() {
+		It("decode on register & change", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+			Expect(err).Should(Succeed())
+
+			conf := c.GetNacosConfig().(*MongoConf)
+			Expect(conf.Host == "mongodb://server:27017/monkey").Should(BeTrue())
+			Expect(conf.MaxPoolSize == 150).Should(BeTrue())
+
+			v, flag := c.GetConfigWithFlag()
+			Expect(v == conf).Should(BeTrue())
+			Expect(flag == primitive.OnlyNacos).Should(BeTrue())
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://other:27017/monkey"})
+
+			changed := c.GetNacosConfig().(*MongoConf)
+			Expect(changed.Host == "mongodb://other:27017/monkey").Should(BeTrue())
+			Expect(conf.Host == "mongodb://server:27017/monkey").Should(BeTrue())
+		})
+
+		It("keep last good config", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			var reported error
+			c.OnError(func(name string, source primitive.Flag, err error) {
+				Expect(source == primitive.OnlyNacos).Should(BeTrue())
+				reported = err
+			})
+
+			err := c.RegisterNacosStructWithName("app", dataID, group, &MongoConf{})
+			Expect(err).Should(Succeed())
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: ["})
+			Expect(reported).Should(HaveOccurred())
+
+			conf := c.GetNacosConfigByName("app").(*MongoConf)
+			Expect(conf.Host == "mongodb://server:27017/monkey").Should(BeTrue())
+		})
+
+		It("invalid content", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: ["})
+
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+			Expect(err).ShouldNot(Succeed())
+
+			err = c.RegisterNacosStruct(dataID, group, MongoConf{})
+			Expect(err).Should(Equal(primitive.ErrMustBePointer))
+		})
+
+		It("raw content", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			err := c.RegisterNacos(dataID, group)
+			Expect(err).Should(Succeed())
+			Expect(c.GetNacosConfig().(string) == mongoContent).Should(BeTrue())
+		})
+
+		It("not dial nacos", func() {
+			c := NewConfigIns()
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+			Expect(err).Should(Equal(primitive.ErrDialNacosFirst))
+		})
+	})
+
+	Context("registration", func() {
+		It("same dataID & group in one instance", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			Expect(c.RegisterNacosStructWithName("a", dataID, group, &MongoConf{})).Should(Succeed())
+
+			err := c.RegisterNacosStructWithName("b", dataID, group, &MongoConf{})
+			Expect(err).Should(Equal(primitive.ErrDataIDAndGroupAlreadyRegister))
+
+			err = c.RegisterMixedWithName("c", "mixed.yaml", dataID, group, &MongoConf{})
+			Expect(err).Should(Equal(primitive.ErrDataIDAndGroupAlreadyRegister))
+			Expect(c.GetMixedConfigByName("c")).Should(BeNil())
+		})
+
+		It("shared listener", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			Expect(c.RegisterNacosStructWithName("a", dataID, group, &MongoConf{})).Should(Succeed())
+			Expect(c.RegisterNacosStructWithName("b", dataID, group, &MongoConf{}, primitive.WithSharedListener())).Should(Succeed())
+			Expect(c.RegisterMixedWithName("c", "mixed.yaml", dataID, group, &MongoConf{}, primitive.WithSharedListener())).Should(Succeed())
+
+			// 只监听一次
+			Expect(client.Listening(dataID, group) == 1).Should(BeTrue())
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://other:27017/monkey"})
+			Expect(c.GetNacosConfigByName("a").(*MongoConf).Host == "mongodb://other:27017/monkey").Should(BeTrue())
+			Expect(c.GetNacosConfigByName("b").(*MongoConf).Host == "mongodb://other:27017/monkey").Should(BeTrue())
+			Expect(c.GetMixedConfigByName("c").(*MongoConf).Host == "mongodb://other:27017/monkey").Should(BeTrue())
+		})
+
+		It("different instances", func() {
+			c1, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			c2 := NewConfigIns(primitive.WithNacosClient(client, ""))
+
+			Expect(c1.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+			Expect(c2.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: mongodb://other:27017/monkey"})
+			Expect(c1.GetNacosConfig().(*MongoConf).Host == "mongodb://other:27017/monkey").Should(BeTrue())
+			Expect(c2.GetNacosConfig().(*MongoConf).Host == "mongodb://other:27017/monkey").Should(BeTrue())
+		})
+	})
+
+	Context("dispatch", func() {
+		It("multiple mixed configs", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: "mongo-a", Group: group, Content: "host: a"})
+			client.PublishConfig(vo.ConfigParam{DataId: "mongo-b", Group: group, Content: "host: b"})
+
+			Expect(c.RegisterMixedWithName("a", "mixed.yaml", "mongo-a", group, &MongoConf{})).Should(Succeed())
+			Expect(c.RegisterMixedWithName("b", "mixed.yaml", "mongo-b", group, &MongoConf{})).Should(Succeed())
+
+			client.PublishConfig(vo.ConfigParam{DataId: "mongo-a", Group: group, Content: "host: a2"})
+			Expect(c.GetMixedConfigByName("a").(*MongoConf).Host == "a2").Should(BeTrue())
+			Expect(c.GetMixedConfigByName("b").(*MongoConf).Host == "b").Should(BeTrue())
+
+			client.PublishConfig(vo.ConfigParam{DataId: "mongo-b", Group: group, Content: "host: b2"})
+			Expect(c.GetMixedConfigByName("a").(*MongoConf).Host == "a2").Should(BeTrue())
+			Expect(c.GetMixedConfigByName("b").(*MongoConf).Host == "b2").Should(BeTrue())
+		})
+
+		It("same dataID in different groups", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: "group-a", Content: "host: a"})
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: "group-b", Content: "host: b"})
+
+			Expect(c.RegisterMixedWithName("a", "mixed.yaml", dataID, "group-a", &MongoConf{})).Should(Succeed())
+			Expect(c.RegisterNacosStructWithName("b", dataID, "group-b", &MongoConf{})).Should(Succeed())
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: "group-b", Content: "host: b2"})
+			Expect(c.GetMixedConfigByName("a").(*MongoConf).Host == "a").Should(BeTrue())
+			Expect(c.GetNacosConfigByName("b").(*MongoConf).Host == "b2").Should(BeTrue())
+		})
+
+		It("other namespace", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+			Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+
+			c.onChange("other", group, dataID, "host: b")
+			Expect(c.GetMixedConfig().(*MongoConf).Host == "a").Should(BeTrue())
+		})
+	})
+
+	Context("client", func() {
+		It("use nacos client", func() {
+			client := configtest.NewClient()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: mongoContent})
+
+			c := NewConfigIns()
+			Expect(c.RegisterNacos(dataID, group)).Should(Equal(primitive.ErrDialNacosFirst))
+
+			Expect(c.UseNacosClient(client, "")).Should(Succeed())
+			Expect(c.RegisterNacos(dataID, group)).Should(Succeed())
+
+			Expect(c.UseNacosClient(client, "")).ShouldNot(Succeed())
+			Expect(c.DailNacos("localhost:8848", "")).ShouldNot(Succeed())
+			Expect(NewConfigIns().UseNacosClient(nil, "")).ShouldNot(Succeed())
+
+			c = NewConfigIns()
+			Expect(c.Close(context.Background())).Should(Succeed())
+			Expect(c.UseNacosClient(client, "")).Should(Equal(primitive.ErrClosed))
+		})
+
+		It("client options", func() {
+			c := NewConfigIns(
+				primitive.WithLogDir("/var/log/nacos"),
+				primitive.WithCacheDir("/var/cache/nacos"),
+				primitive.WithClientOptions(primitive.WithLogLevel("info")),
+			)
+
+			cc := constant.NewClientConfig(clientOptions(c.options)...)
+			Expect(cc.LogDir == "/var/log/nacos").Should(BeTrue())
+			Expect(cc.CacheDir == "/var/cache/nacos").Should(BeTrue())
+			Expect(cc.LogLevel == "info").Should(BeTrue())
+			Expect(cc.CustomLogger == nil).Should(BeTrue())
+
+			// 默认不输出到标准输出
+			cc = clientConfig("localhost:8848", "", clientOptions(c.options)...)
+			Expect(cc.AppendToStdout).Should(BeFalse())
+			Expect(cc.LogLevel == "info").Should(BeTrue())
+
+			c = NewConfigIns(primitive.WithClientOptions(primitive.WithLogStdout(true)))
+			cc = clientConfig("localhost:8848", "", clientOptions(c.options)...)
+			Expect(cc.AppendToStdout).Should(BeTrue())
+		})
+	})
+})
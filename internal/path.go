@@ -0,0 +1,247 @@
+package internal
+
+import (
+	. "config/primitive"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetString 获取默认配置中path对应的值，path由"."分隔，e.g. envs.local.redis.host
+func (c *configIns) GetString(path string) (string, error) {
+	return c.GetStringByName(defaultName, path)
+}
+
+// GetInt 获取默认配置中path对应的值
+func (c *configIns) GetInt(path string) (int, error) {
+	return c.GetIntByName(defaultName, path)
+}
+
+// GetBool 获取默认配置中path对应的值
+func (c *configIns) GetBool(path string) (bool, error) {
+	return c.GetBoolByName(defaultName, path)
+}
+
+// GetDuration 获取默认配置中path对应的值
+func (c *configIns) GetDuration(path string) (time.Duration, error) {
+	return c.GetDurationByName(defaultName, path)
+}
+
+// GetStringSlice 获取默认配置中path对应的值
+func (c *configIns) GetStringSlice(path string) ([]string, error) {
+	return c.GetStringSliceByName(defaultName, path)
+}
+
+// GetStringMap 获取默认配置中path对应的值
+func (c *configIns) GetStringMap(path string) (map[string]interface{}, error) {
+	return c.GetStringMapByName(defaultName, path)
+}
+
+// GetStringByName 获取指定配置中path对应的值，数值、bool会转换为字符串
+func (c *configIns) GetStringByName(name, path string) (string, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", mismatch(path, "string", v)
+	case string:
+		return value, nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// GetIntByName 获取指定配置中path对应的值，字符串会按照十进制解析
+func (c *configIns) GetIntByName(name, path string) (int, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := v.(type) {
+	case int:
+		return value, nil
+	case int64:
+		if int64(int(value)) == value {
+			return int(value), nil
+		}
+	case uint64:
+		if value <= math.MaxInt {
+			return int(value), nil
+		}
+	case float64:
+		if value == float64(int(value)) {
+			return int(value), nil
+		}
+	case string:
+		if n, err := strconv.Atoi(value); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, mismatch(path, "int", v)
+}
+
+// GetBoolByName 获取指定配置中path对应的值
+func (c *configIns) GetBoolByName(name, path string) (bool, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return false, err
+	}
+
+	switch value := v.(type) {
+	case bool:
+		return value, nil
+	case string:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b, nil
+		}
+	}
+
+	return false, mismatch(path, "bool", v)
+}
+
+// GetDurationByName 获取指定配置中path对应的值，支持"10s"格式；数值与yaml解析time.Duration的规则一致
+func (c *configIns) GetDurationByName(name, path string) (time.Duration, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := v.(type) {
+	case int, int64, uint64:
+		if d, err := parseDuration(fmt.Sprint(value)); err == nil {
+			return d, nil
+		}
+	case string:
+		if d, err := parseDuration(value); err == nil {
+			return d, nil
+		}
+	}
+
+	return 0, mismatch(path, "duration", v)
+}
+
+// GetStringSliceByName 获取指定配置中path对应的值，元素需要为标量
+func (c *configIns) GetStringSliceByName(name, path string) ([]string, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, mismatch(path, "[]string", v)
+	}
+
+	s := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			return nil, mismatch(path, "[]string", v)
+		}
+
+		s = append(s, fmt.Sprint(item))
+	}
+
+	return s, nil
+}
+
+// GetStringMapByName 获取指定配置中path对应的值，path为空时返回整个配置
+func (c *configIns) GetStringMapByName(name, path string) (map[string]interface{}, error) {
+	v, err := c.lookup(name, path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, mismatch(path, "map[string]interface{}", v)
+	}
+
+	return m, nil
+}
+
+// mismatch 生成类型不匹配的错误
+func mismatch(path, want string, v interface{}) error {
+	return fmt.Errorf("%w: %s is %T, not %s", ErrTypeMismatch, path, v, want)
+}
+
+// tree 将name对应的配置转换为以map[string]interface{}表示的树，nacos原始内容按照其格式解析
+func (c *configIns) tree(name string) (interface{}, error) {
+	v, flag := c.GetConfigWithFlagByName(name)
+	if v == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+	}
+
+	if flag != OnlyNacos {
+		return toTree(v)
+	}
+
+	// 内容与codec来自同一个配置，期间可能已被注销
+	nc, exist := c.load().nacos[name]
+	if !exist {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+	}
+
+	if nc.proto != nil {
+		return toTree(v)
+	}
+
+	var tree interface{}
+	if err := nc.codec.Unmarshal([]byte(nc.content()), &tree); err != nil {
+		return nil, err
+	}
+
+	return stringKeys(tree), nil
+}
+
+// lookup 获取name对应配置中path的值，slice通过下标访问，e.g. hosts.0
+func (c *configIns) lookup(name, path string) (interface{}, error) {
+	node, err := c.tree(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return node, nil
+	}
+
+	node, exist := walk(node, strings.Split(path, "."))
+	if !exist {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+
+	return node, nil
+}
+
+// walk 按照路径访问树中的节点
+func walk(node interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		switch value := node.(type) {
+		case map[string]interface{}:
+			child, exist := value[key]
+			if !exist {
+				return nil, false
+			}
+			node = child
+
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(value) {
+				return nil, false
+			}
+			node = value[idx]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
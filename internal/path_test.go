@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path", func() {
+	Context("file mode", func() {
+		var c *configIns
+
+		BeforeEach(func() {
+			c = NewConfigIns()
+			Expect(c.RegisterFile("default.yaml", &GeneralConfig{})).Should(Succeed())
+			Expect(c.RegisterFileWithName("app", "app.yaml", &GeneralConfig{})).Should(Succeed())
+		})
+
+		It("typed getters", func() {
+			host, err := c.GetString("envs.local.redis.host")
+			Expect(err).Should(Succeed())
+			Expect(host == "127.0.0.1").Should(BeTrue())
+
+			port, err := c.GetInt("envs.local.redis.port")
+			Expect(err).Should(Succeed())
+			Expect(port == 6379).Should(BeTrue())
+
+			portStr, err := c.GetString("envs.local.redis.port")
+			Expect(err).Should(Succeed())
+			Expect(portStr == "6379").Should(BeTrue())
+
+			wait, err := c.GetBool("envs.local.redis.wait")
+			Expect(err).Should(Succeed())
+			Expect(wait).Should(BeTrue())
+
+			timeout, err := c.GetDuration("envs.local.redis.read_timeout")
+			Expect(err).Should(Succeed())
+			Expect(timeout == 10000*time.Nanosecond).Should(BeTrue())
+
+			redis, err := c.GetStringMap("envs.local.redis")
+			Expect(err).Should(Succeed())
+			Expect(redis["host"] == "127.0.0.1").Should(BeTrue())
+
+			name, err := c.GetStringByName("app", "envs.local.product_name")
+			Expect(err).Should(Succeed())
+			Expect(name == "app").Should(BeTrue())
+		})
+
+		It("errors", func() {
+			_, err := c.GetString("envs.prod.redis.host")
+			Expect(errors.Is(err, primitive.ErrPathNotFound)).Should(BeTrue())
+
+			_, err = c.GetInt("envs.local.redis.host")
+			Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+
+			_, err = c.GetString("envs.local.redis")
+			Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+
+			_, err = c.GetStringSlice("envs.local")
+			Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+
+			_, err = c.GetStringByName("unknown", "cur_env")
+			Expect(errors.Is(err, primitive.ErrNotRegistered)).Should(BeTrue())
+		})
+	})
+
+	It("nacos raw content", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: "mongo.json", Group: group, Content: `{"db": "monkey", "hosts": ["a", "b"], "pool": {"max": 150, "limit": 18446744073709551615}}`})
+
+		Expect(c.RegisterNacos("mongo.json", group)).Should(Succeed())
+
+		db, err := c.GetString("db")
+		Expect(err).Should(Succeed())
+		Expect(db == "monkey").Should(BeTrue())
+
+		hosts, err := c.GetStringSlice("hosts")
+		Expect(err).Should(Succeed())
+		Expect(hosts).Should(Equal([]string{"a", "b"}))
+
+		host, err := c.GetString("hosts.1")
+		Expect(err).Should(Succeed())
+		Expect(host == "b").Should(BeTrue())
+
+		max, err := c.GetInt("pool.max")
+		Expect(err).Should(Succeed())
+		Expect(max == 150).Should(BeTrue())
+
+		// 超出int范围时返回类型错误
+		_, err = c.GetInt("pool.limit")
+		Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+	})
+})
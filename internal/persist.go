@@ -0,0 +1,131 @@
+package internal
+
+import (
+	. "config/primitive"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultSnapshotNamespace namespace为空时快照文件使用的目录名
+const defaultSnapshotNamespace = "public"
+
+// savedConfig 本地快照文件的内容，服务端不可用时用于启动
+type savedConfig struct {
+	Namespace string    `json:"namespace"`
+	DataID    string    `json:"data_id"`
+	Group     string    `json:"group"`
+	Content   string    `json:"content"`
+	MD5       string    `json:"md5"`        // Content的摘要，读取时校验
+	UpdatedAt time.Time `json:"updated_at"` // 从服务端获取的时间
+}
+
+// snapshotPath 快照文件路径：dir/namespace/group/dataID.json
+func snapshotPath(dir, namespace, dataID, group string) string {
+	if namespace == "" {
+		namespace = defaultSnapshotNamespace
+	}
+
+	return filepath.Join(dir, url.PathEscape(namespace), url.PathEscape(group), url.PathEscape(dataID)+".json")
+}
+
+// checksum 配置内容的摘要
+func checksum(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// saveSnapshot 保存从服务端获取的配置内容，未指定WithSnapshotDir时不保存
+//  先写入临时文件再替换，避免进程退出时留下不完整的文件
+func (c *configIns) saveSnapshot(namespace, dataID, group, content string) error {
+	if c.options.SnapshotDir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(&savedConfig{
+		Namespace: namespace,
+		DataID:    dataID,
+		Group:     group,
+		Content:   content,
+		MD5:       checksum(content),
+		UpdatedAt: time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	file := snapshotPath(c.options.SnapshotDir, namespace, dataID, group)
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// loadSnapshot 读取本地快照，未指定WithSnapshotDir、文件不存在时返回nil，摘要不一致时返回错误
+func (c *configIns) loadSnapshot(namespace, dataID, group string) (*savedConfig, error) {
+	if c.options.SnapshotDir == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(snapshotPath(c.options.SnapshotDir, namespace, dataID, group))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	saved := &savedConfig{}
+	if err = json.Unmarshal(data, saved); err != nil {
+		return nil, err
+	}
+
+	if saved.MD5 != checksum(saved.Content) {
+		return nil, errors.New("snapshot checksum mismatch: " + dataID + "@" + group)
+	}
+
+	return saved, nil
+}
+
+// fallbackSnapshot 服务端不可用时读取本地快照代替服务端的内容，快照不可用时返回原错误
+func (c *configIns) fallbackSnapshot(name string, source Flag, namespace, dataID, group string, err error) (*savedConfig, error) {
+	if !errors.Is(err, ErrConnectFailed) {
+		return nil, err
+	}
+
+	saved, e := c.loadSnapshot(namespace, dataID, group)
+	if e != nil {
+		c.reportError(name, source, e)
+	}
+
+	if saved == nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
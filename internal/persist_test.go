@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cachedClient 与nacos客户端一致，服务端不可用时GetConfig返回上一次获取的内容且没有错误
+type cachedClient struct {
+	*configtest.Client
+	down  int32
+	mutex sync.Mutex
+	cache map[string]string
+}
+
+func newCachedClient() *cachedClient {
+	return &cachedClient{Client: configtest.NewClient(), cache: map[string]string{}}
+}
+
+func (cc *cachedClient) GetConfig(param vo.ConfigParam) (string, error) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	key := param.DataId + "@" + param.Group
+	if atomic.LoadInt32(&cc.down) == 1 {
+		return cc.cache[key], nil
+	}
+
+	content, err := cc.Client.GetConfig(param)
+	if err == nil {
+		cc.cache[key] = content
+	}
+
+	return content, err
+}
+
+func (cc *cachedClient) SearchConfig(param vo.SearchConfigParam) (*model.ConfigPage, error) {
+	if atomic.LoadInt32(&cc.down) == 1 {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+
+	return cc.Client.SearchConfig(param)
+}
+
+var _ = Describe("Persist", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "snapshot")
+		Expect(err).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	readSaved := func(namespace, dataID, group string) *savedConfig {
+		data, err := ioutil.ReadFile(snapshotPath(dir, namespace, dataID, group))
+		Expect(err).Should(Succeed())
+
+		saved := &savedConfig{}
+		Expect(json.Unmarshal(data, saved)).Should(Succeed())
+		return saved
+	}
+
+	It("save on register & change", func() {
+		client := configtest.NewClient()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+		c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithSnapshotDir(dir))
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+		saved := readSaved("", dataID, group)
+		Expect(saved.Content == "host: a").Should(BeTrue())
+		Expect(saved.MD5 == checksum("host: a")).Should(BeTrue())
+		Expect(saved.UpdatedAt.IsZero()).Should(BeFalse())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(readSaved("", dataID, group).Content == "host: b").Should(BeTrue())
+
+		// 解析失败的内容不保存
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: ["})
+		Expect(readSaved("", dataID, group).Content == "host: b").Should(BeTrue())
+	})
+
+	It("boot from snapshot", func() {
+		client := &flakyClient{Client: configtest.NewClient(), failures: 1 << 30}
+		Expect(newSnapshotIns(dir).saveSnapshot("", dataID, group, "host: saved")).Should(Succeed())
+		Expect(newSnapshotIns(dir).saveSnapshot("", "redis", group, "host: saved")).Should(Succeed())
+
+		c := NewConfigIns(primitive.WithNacosClient(client, ""))
+		err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+		Expect(errors.Is(err, primitive.ErrNetwork)).Should(BeTrue())
+
+		c = NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithSnapshotDir(dir))
+		defer c.Close(context.Background())
+
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "saved").Should(BeTrue())
+
+		Expect(c.RegisterMixedWithName("redis", "mixed.yaml", "redis", group, &MongoConf{})).Should(Succeed())
+		Expect(c.GetMixedConfigByName("redis").(*MongoConf).Host == "saved").Should(BeTrue())
+
+		h := c.Health()
+		Expect(h.Syncs).Should(HaveLen(2))
+		for _, s := range h.Syncs {
+			Expect(s.Stale).Should(BeTrue())
+			Expect(s.SnapshotAt.IsZero()).Should(BeFalse())
+			Expect(s.LastSync.IsZero()).Should(BeTrue())
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(c.Ready(ctx, defaultName)).Should(Equal(context.DeadlineExceeded))
+
+		// 服务端恢复后不再标记为Stale
+		atomic.StoreInt32(&client.failures, 0)
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: server"})
+		Expect(c.Ready(context.Background(), defaultName)).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "server").Should(BeTrue())
+		Expect(c.Health().Syncs[0].Stale).Should(BeFalse())
+	})
+
+	It("ignore client cache", func() {
+		client := newCachedClient()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: cached"})
+		client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		atomic.StoreInt32(&client.down, 1)
+
+		Expect(newSnapshotIns(dir).saveSnapshot("", dataID, group, "host: saved")).Should(Succeed())
+		c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithSnapshotDir(dir))
+		defer c.Close(context.Background())
+
+		// 服务端不可用时使用快照，不使用客户端的缓存，也不覆盖快照
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "saved").Should(BeTrue())
+		Expect(readSaved("", dataID, group).Content == "host: saved").Should(BeTrue())
+
+		h := c.Health()
+		Expect(h.Syncs[0].Stale && h.Syncs[0].LastSync.IsZero()).Should(BeTrue())
+	})
+
+	It("fill right after register", func() {
+		Expect(newSnapshotIns(dir).saveSnapshot("", dataID, group, "host: saved")).Should(Succeed())
+
+		// 注册时服务端不可用，后台填充立即成功，之后不再标记为Stale
+		for i := 0; i < 20; i++ {
+			client := &flakyClient{Client: configtest.NewClient(), failures: 1}
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: server"})
+
+			c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithSnapshotDir(dir), primitive.WithRetry(primitive.Backoff{Initial: time.Nanosecond}))
+			Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+			Expect(c.Ready(context.Background(), defaultName)).Should(Succeed())
+			Expect(c.Health().Syncs[0].Stale).Should(BeFalse())
+			c.Close(context.Background())
+		}
+	})
+
+	It("checksum mismatch", func() {
+		saver := newSnapshotIns(dir)
+		Expect(saver.saveSnapshot("", dataID, group, "host: saved")).Should(Succeed())
+
+		saved := readSaved("", dataID, group)
+		saved.Content = "host: modified"
+		data, _ := json.Marshal(saved)
+		Expect(ioutil.WriteFile(snapshotPath(dir, "", dataID, group), data, 0644)).Should(Succeed())
+
+		client := &flakyClient{Client: configtest.NewClient(), failures: 1 << 30}
+		c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithSnapshotDir(dir))
+
+		var reported error
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			reported = err
+		})
+
+		err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+		Expect(errors.Is(err, primitive.ErrNetwork)).Should(BeTrue())
+		Expect(reported).Should(HaveOccurred())
+	})
+})
+
+// newSnapshotIns 只用于读写快照的实例
+func newSnapshotIns(dir string) *configIns {
+	return NewConfigIns(primitive.WithSnapshotDir(dir))
+}
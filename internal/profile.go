@@ -0,0 +1,56 @@
+package internal
+
+import (
+	. "config/primitive"
+	"fmt"
+	"os"
+)
+
+const (
+	curEnvKey = "cur_env" // 配置中表示当前环境的key
+	envsKey   = "envs"    // 配置中各环境配置的key
+)
+
+// resolveProfile 确定当前环境名称：指定的profile -> 环境变量 -> 配置中的cur_env
+func resolveProfile(tree map[string]interface{}, options *RegisterOptions) string {
+	if options.Profile != "" {
+		return options.Profile
+	}
+
+	if options.ProfileEnv != "" {
+		if profile := os.Getenv(options.ProfileEnv); profile != "" {
+			return profile
+		}
+	}
+
+	if profile, ok := tree[curEnvKey]; ok && profile != nil {
+		return fmt.Sprint(profile)
+	}
+
+	return ""
+}
+
+// selectProfile 从cur_env/envs结构的配置中取出当前环境的配置内容
+func selectProfile(codec Codec, data []byte, options *RegisterOptions) ([]byte, error) {
+	tree := map[string]interface{}{}
+	if err := codec.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	profile := resolveProfile(tree, options)
+	if profile == "" {
+		return nil, fmt.Errorf("%w: %s is empty and no profile specified", ErrProfileNotFound, curEnvKey)
+	}
+
+	envs, ok := stringKeys(tree[envsKey]).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, missing %s", ErrProfileNotFound, profile, envsKey)
+	}
+
+	block, ok := envs[profile]
+	if !ok || block == nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+	}
+
+	return codec.Marshal(block)
+}
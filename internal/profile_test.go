@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Profile", func() {
+	It("cur_env", func() {
+		c := NewConfigIns()
+		err := c.RegisterFile("default.yaml", &Configure{}, primitive.WithProfile(""))
+		Expect(err).Should(Succeed())
+
+		conf := c.GetFileConfig().(*Configure)
+		Expect(conf.ProductName == "default").Should(BeTrue())
+		Expect(conf.Port == 9900).Should(BeTrue())
+		Expect(conf.Redis.Port == 6379).Should(BeTrue())
+	})
+
+	It("explicit profile", func() {
+		c := NewConfigIns()
+		err := c.RegisterFile("app.yaml", &Configure{}, primitive.WithProfile("local"))
+		Expect(err).Should(Succeed())
+		Expect(c.GetFileConfig().(*Configure).ProductName == "app").Should(BeTrue())
+	})
+
+	It("environment variable", func() {
+		os.Setenv("CONFIG_TEST_ENV", "prod")
+		defer os.Unsetenv("CONFIG_TEST_ENV")
+
+		c := NewConfigIns()
+		err := c.RegisterFile("default.yaml", &Configure{}, primitive.WithProfileEnv("CONFIG_TEST_ENV"))
+		Expect(errors.Is(err, primitive.ErrProfileNotFound)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("prod"))
+
+		// 显式指定的profile优先
+		err = c.RegisterFile("default.yaml", &Configure{}, primitive.WithProfileEnv("CONFIG_TEST_ENV"), primitive.WithProfile("local"))
+		Expect(err).Should(Succeed())
+	})
+
+	It("missing envs", func() {
+		c := NewConfigIns()
+		err := c.RegisterFile("mixed.yaml", &Configure{}, primitive.WithProfile(""))
+		Expect(errors.Is(err, primitive.ErrProfileNotFound)).Should(BeTrue())
+
+		err = c.RegisterFile("mixed.yaml", &Configure{}, primitive.WithProfile("local"))
+		Expect(errors.Is(err, primitive.ErrProfileNotFound)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	. "config/primitive"
+	"fmt"
+	"reflect"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// publishTarget 发布、删除配置的目标dataID和group
+type publishTarget struct {
+	dataID  string
+	group   string
+	codec   Codec
+	proto   interface{}      // 为nil时只能发布原始内容
+	verify  bool             // 原始内容按照codec校验
+	options *RegisterOptions // 注册选项
+	seen    string           // 当前实例最近一次获取到的内容
+}
+
+// Publish 将value按照dataID的格式序列化后写入name注册的dataID和group，只支持混合、nacos模式
+//  value的类型需要与注册时一致并通过校验；按照原始内容注册时value为string或[]byte。
+//  写入后由监听更新本地配置；混合模式写入的是整个配置对象，包括来自配置文件的字段
+func (c *configIns) Publish(name string, value interface{}, opts ...PublishOption) error {
+	s, t, err := c.publishTarget(name)
+	if err != nil {
+		return err
+	}
+
+	content, err := t.marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.pubMutex.Lock()
+	defer c.pubMutex.Unlock()
+
+	if err = compare(s.client, t, NewPublishOptions(opts...)); err != nil {
+		return err
+	}
+
+	ok, err := s.client.PublishConfig(vo.ConfigParam{DataId: t.dataID, Group: t.group, Content: content})
+	if err != nil {
+		return fmt.Errorf("%w: %s/%s: %v", ErrPublishFailed, t.group, t.dataID, err)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s/%s", ErrPublishFailed, t.group, t.dataID)
+	}
+
+	return nil
+}
+
+// Delete 删除name注册的dataID和group在服务端的配置，只支持混合、nacos模式
+//  删除后nacos模式保留上一次有效的配置，混合模式恢复为配置文件的内容
+func (c *configIns) Delete(name string, opts ...PublishOption) error {
+	s, t, err := c.publishTarget(name)
+	if err != nil {
+		return err
+	}
+
+	c.pubMutex.Lock()
+	defer c.pubMutex.Unlock()
+
+	if err = compare(s.client, t, NewPublishOptions(opts...)); err != nil {
+		return err
+	}
+
+	ok, err := s.client.DeleteConfig(vo.ConfigParam{DataId: t.dataID, Group: t.group})
+	if err != nil {
+		return fmt.Errorf("%w: %s/%s: %v", ErrPublishFailed, t.group, t.dataID, err)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s/%s", ErrPublishFailed, t.group, t.dataID)
+	}
+
+	return nil
+}
+
+// publishTarget 获取name对应的dataID和group，文件、分层模式以及WithProfile注册的配置不能发布
+func (c *configIns) publishTarget(name string) (*snapshot, *publishTarget, error) {
+	s := c.load()
+	if s.closed {
+		return nil, nil, ErrClosed
+	}
+
+	if s.client == nil {
+		return nil, nil, ErrDialNacosFirst
+	}
+
+	var t *publishTarget
+	if mc, exist := s.mixed[name]; exist {
+		codec, err := codecByDataID(mc.dataID, mc.options.Format)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		t = &publishTarget{
+			dataID:  mc.dataID,
+			group:   mc.group,
+			codec:   codec,
+			proto:   mc.proto,
+			options: mc.options,
+			seen:    mc.nacosContent(),
+		}
+	} else if nc, exist := s.nacos[name]; exist {
+		t = &publishTarget{
+			dataID:  nc.dataID,
+			group:   nc.group,
+			codec:   nc.codec,
+			proto:   nc.proto,
+			verify:  nc.verify,
+			options: nc.options,
+			seen:    nc.content(),
+		}
+	} else if _, inFile := s.files[name]; inFile {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotPublishable, name)
+	} else if _, inLayered := s.layered[name]; inLayered {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotPublishable, name)
+	} else {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+	}
+
+	// 按照环境区分的内容无法由单个环境的配置还原
+	if t.options.UseProfile {
+		return nil, nil, fmt.Errorf("%w: %s registered with profile", ErrNotPublishable, name)
+	}
+
+	return s, t, nil
+}
+
+// marshal 将value序列化为写入nacos的内容
+func (t *publishTarget) marshal(value interface{}) (string, error) {
+	if t.proto == nil {
+		var content string
+		switch v := value.(type) {
+		case string:
+			content = v
+		case []byte:
+			content = string(v)
+		default:
+			return "", mismatch(t.group+"/"+t.dataID, "string", value)
+		}
+
+		if t.verify {
+			var tree interface{}
+			if err := t.codec.Unmarshal([]byte(content), &tree); err != nil {
+				return "", err
+			}
+		}
+
+		return content, nil
+	}
+
+	if value == nil || reflect.TypeOf(value) != reflect.TypeOf(t.proto) {
+		return "", mismatch(t.group+"/"+t.dataID, reflect.TypeOf(t.proto).String(), value)
+	}
+
+	if err := validate(value); err != nil {
+		return "", err
+	}
+
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// compare WithCAS、WithExpectedMD5时比较服务端当前内容的md5，不一致时返回ErrConflict
+//  nacos客户端不支持带md5的原子写入，比较与写入之间仍可能被其他客户端修改
+func compare(client INacosClient, t *publishTarget, options *PublishOptions) error {
+	if !options.CAS && options.MD5 == "" {
+		return nil
+	}
+
+	current, err := client.GetConfig(vo.ConfigParam{DataId: t.dataID, Group: t.group})
+	if err != nil {
+		return err
+	}
+
+	sum := checksum(current)
+	if options.CAS && sum != checksum(t.seen) {
+		return fmt.Errorf("%w: %s/%s, seen %s, current %s", ErrConflict, t.group, t.dataID, checksum(t.seen), sum)
+	}
+
+	if options.MD5 != "" && sum != options.MD5 {
+		return fmt.Errorf("%w: %s/%s, expected %s, current %s", ErrConflict, t.group, t.dataID, options.MD5, sum)
+	}
+
+	return nil
+}
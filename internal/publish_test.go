@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Publish", func() {
+	It("nacos", func() {
+		c, client := newMixedIns()
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+		conf := *c.GetNacosConfig().(*MongoConf)
+		conf.MaxPoolSize = 200
+		Expect(c.Publish(defaultName, &conf)).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).MaxPoolSize == 200).Should(BeTrue())
+
+		content, _ := client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(content).Should(ContainSubstring("max_pool_size: 200"))
+
+		err := c.Publish(defaultName, conf)
+		Expect(errors.Is(err, primitive.ErrTypeMismatch)).Should(BeTrue())
+
+		err = c.Publish("none", &conf)
+		Expect(errors.Is(err, primitive.ErrNotRegistered)).Should(BeTrue())
+	})
+
+	It("raw & validate", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: "app.json", Group: group, Content: `{"redis": {"host": "a"}}`})
+		Expect(c.RegisterNacosWithName("raw", "app.json", group)).Should(Succeed())
+
+		Expect(c.Publish("raw", []byte(`{"redis": {"host": "b"}}`))).Should(Succeed())
+		Expect(c.GetNacosConfigByName("raw") == `{"redis": {"host": "b"}}`).Should(BeTrue())
+		Expect(c.Publish("raw", `{"log_level": `)).ShouldNot(Succeed())
+		Expect(c.Publish("raw", 1)).ShouldNot(Succeed())
+
+		Expect(c.RegisterNacosStructWithName("struct", "app.json", group, &ValidateConfig{}, primitive.WithSharedListener())).Should(Succeed())
+		redis := ValidateRedisConf{Host: "c", Port: 6379}
+		err := c.Publish("struct", &ValidateConfig{LogLevel: "none", Hosts: []string{"a"}, Redis: redis})
+		Expect(errors.Is(err, primitive.ErrInvalidConfig)).Should(BeTrue())
+
+		// json格式按照yaml tag序列化
+		Expect(c.Publish("struct", &ValidateConfig{LogLevel: "error", Hosts: []string{"a"}, Redis: redis})).Should(Succeed())
+		Expect(c.GetNacosConfigByName("struct").(*ValidateConfig).LogLevel == "error").Should(BeTrue())
+		Expect(c.GetNacosConfigByName("raw").(string)).Should(ContainSubstring(`"log_level": "error"`))
+	})
+
+	It("mixed & delete", func() {
+		f, err := ioutil.TempFile("", "publish*.yaml")
+		Expect(err).Should(Succeed())
+		defer os.Remove(f.Name())
+
+		_, err = f.WriteString(mergeFile)
+		Expect(err).Should(Succeed())
+		Expect(f.Close()).Should(Succeed())
+
+		c, client := newMixedIns()
+		Expect(c.RegisterMixed(f.Name(), dataID, group, &MergeConf{})).Should(Succeed())
+
+		Expect(c.Publish(defaultName, &MergeConf{Name: "published"})).Should(Succeed())
+		Expect(c.GetMixedConfig().(*MergeConf).Name == "published").Should(BeTrue())
+
+		// 删除后恢复为配置文件的内容
+		Expect(c.Delete(defaultName)).Should(Succeed())
+		content, _ := client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		Expect(content == "").Should(BeTrue())
+		Expect(c.GetMixedConfig().(*MergeConf).Name == "file").Should(BeTrue())
+	})
+
+	It("nacos delete", func() {
+		c, client := newMixedIns()
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+		client.PublishConfig(vo.ConfigParam{DataId: "app.json", Group: group, Content: `{"host": "a"}`})
+		Expect(c.RegisterNacosWithName("raw", "app.json", group)).Should(Succeed())
+
+		var reported error
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			reported = err
+		})
+
+		// 删除后保留上一次有效的配置，不作为更新失败
+		before := c.GetNacosConfig()
+		Expect(c.Delete(defaultName)).Should(Succeed())
+		Expect(c.Delete("raw")).Should(Succeed())
+		Expect(c.GetNacosConfig() == before).Should(BeTrue())
+		Expect(c.GetNacosConfigByName("raw") == `{"host": "a"}`).Should(BeTrue())
+		Expect(reported).Should(BeNil())
+		Expect(c.Failures()).Should(BeEmpty())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "b").Should(BeTrue())
+	})
+
+	It("compare", func() {
+		c, client := newMixedIns()
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+
+		conf := &MongoConf{Host: "a"}
+		Expect(c.Publish(defaultName, conf, primitive.WithCAS())).Should(Succeed())
+
+		err := c.Publish(defaultName, conf, primitive.WithExpectedMD5(checksum(mixedContent)))
+		Expect(errors.Is(err, primitive.ErrConflict)).Should(BeTrue())
+
+		e, err := c.Explain(defaultName, "host")
+		Expect(err).Should(Succeed())
+		Expect(c.Delete(defaultName, primitive.WithExpectedMD5(e.Origin.Version))).Should(Succeed())
+
+		// 其他客户端修改后，当前实例尚未收到变更
+		t := &publishTarget{dataID: dataID, group: group, seen: mixedContent}
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		err = compare(client, t, primitive.NewPublishOptions(primitive.WithCAS()))
+		Expect(errors.Is(err, primitive.ErrConflict)).Should(BeTrue())
+
+		t.seen = "host: b"
+		Expect(compare(client, t, primitive.NewPublishOptions(primitive.WithCAS()))).Should(Succeed())
+	})
+
+	It("not publishable", func() {
+		c, client := newTestIns()
+		Expect(c.RegisterFile("mixed.yaml", &MongoConf{})).Should(Succeed())
+		err := c.Publish(defaultName, &MongoConf{})
+		Expect(errors.Is(err, primitive.ErrNotPublishable)).Should(BeTrue())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "cur_env: local\nenvs:\n  local:\n    host: a"})
+		Expect(c.RegisterNacosStructWithName("profile", dataID, group, &MongoConf{}, primitive.WithProfile("local"))).Should(Succeed())
+		err = c.Delete("profile")
+		Expect(errors.Is(err, primitive.ErrNotPublishable)).Should(BeTrue())
+
+		Expect(NewConfigIns().Publish(defaultName, &MongoConf{})).Should(MatchError(primitive.ErrDialNacosFirst))
+	})
+})
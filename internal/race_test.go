@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"config/primitive"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// PairConf A与B始终相等，用于检测读取方是否看到更新了一半的配置
+type PairConf struct {
+	A int `yaml:"a"`
+	B int `yaml:"b"`
+}
+
+func (p *PairConf) UpdateAfterRegister() {}
+
+// OnNacosChanged 逐个字段赋值，在原对象上更新时读取方可能看到A与B不一致
+func (p *PairConf) OnNacosChanged(namespace, group, dataId, data string) error {
+	conf := &PairConf{}
+	if err := yaml.Unmarshal([]byte(data), conf); err != nil {
+		return err
+	}
+
+	p.A = conf.A
+	runtime.Gosched()
+	p.B = conf.B
+	return nil
+}
+
+// slowCodec 解析时让出执行，放大并发更新的窗口
+type slowCodec struct{ yamlCodec }
+
+func (slowCodec) Unmarshal(data []byte, v interface{}) error {
+	time.Sleep(time.Millisecond)
+	return yamlCodec{}.Unmarshal(data, v)
+}
+
+// 以下用例需要通过 go test -race 执行才能发现数据竞争
+var _ = Describe("Race", func() {
+	const n = 8
+
+	It("register & get & onChange in parallel", func() {
+		c, client := newTestIns()
+		for i := 0; i < n; i++ {
+			client.PublishConfig(vo.ConfigParam{DataId: fmt.Sprintf("race-nacos-%d", i), Group: group, Content: "host: a"})
+			client.PublishConfig(vo.ConfigParam{DataId: fmt.Sprintf("race-mixed-%d", i), Group: group, Content: "host: a"})
+		}
+
+		done := make(chan struct{})
+		wg := sync.WaitGroup{}
+
+		// 注册
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				Expect(c.RegisterFileWithName(fmt.Sprintf("file-%d", i), "mixed.yaml", &MongoConf{})).Should(Succeed())
+				Expect(c.RegisterNacosStructWithName(fmt.Sprintf("nacos-%d", i), fmt.Sprintf("race-nacos-%d", i), group, &MongoConf{})).Should(Succeed())
+				Expect(c.RegisterMixedWithName(fmt.Sprintf("mixed-%d", i), "mixed.yaml", fmt.Sprintf("race-mixed-%d", i), group, &MongoConf{})).Should(Succeed())
+			}(i)
+		}
+
+		// 读取
+		readers := sync.WaitGroup{}
+		for i := 0; i < n; i++ {
+			readers.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer readers.Done()
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					if conf, ok := c.GetFileConfigByName(fmt.Sprintf("file-%d", i)).(*MongoConf); ok {
+						Expect(conf.DB == "monkey").Should(BeTrue())
+					}
+
+					if conf, ok := c.GetNacosConfigByName(fmt.Sprintf("nacos-%d", i)).(*MongoConf); ok {
+						Expect(conf.Host != "").Should(BeTrue())
+					}
+
+					c.GetConfigByName(fmt.Sprintf("mixed-%d", i))
+					c.GetStringByName(fmt.Sprintf("file-%d", i), "host")
+				}
+			}(i)
+		}
+
+		// nacos配置变化
+		for i := 0; i < n; i++ {
+			readers.Add(1)
+			go func(i int) {
+				defer readers.Done()
+
+				for j := 0; ; j++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					content := fmt.Sprintf("host: h%d", j)
+					client.PublishConfig(vo.ConfigParam{DataId: fmt.Sprintf("race-nacos-%d", i), Group: group, Content: content})
+				}
+			}(i)
+		}
+
+		wg.Wait()
+		close(done)
+		readers.Wait()
+
+		for i := 0; i < n; i++ {
+			Expect(c.GetFileConfigByName(fmt.Sprintf("file-%d", i))).ShouldNot(BeNil())
+			Expect(c.GetNacosConfigByName(fmt.Sprintf("nacos-%d", i))).ShouldNot(BeNil())
+			Expect(c.GetMixedConfigByName(fmt.Sprintf("mixed-%d", i))).ShouldNot(BeNil())
+		}
+	})
+
+	It("mixed readers never see torn updates", func() {
+		dir, err := ioutil.TempDir("", "race")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "pair.yaml")
+		Expect(ioutil.WriteFile(file, []byte("a: -1\nb: -1"), 0644)).Should(Succeed())
+
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "a: 0\nb: 0"})
+		Expect(c.RegisterMixed(file, dataID, group, &PairConf{})).Should(Succeed())
+
+		first := c.GetMixedConfig().(*PairConf)
+
+		var torn int32
+		done := make(chan struct{})
+		readers := sync.WaitGroup{}
+		for i := 0; i < n; i++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					conf := c.GetMixedConfig().(*PairConf)
+					if conf.A != conf.B {
+						atomic.AddInt32(&torn, 1)
+					}
+					time.Sleep(time.Microsecond)
+				}
+			}()
+		}
+
+		for j := 1; j <= 500; j++ {
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: fmt.Sprintf("a: %d\nb: %d", j, j)})
+		}
+
+		close(done)
+		readers.Wait()
+
+		Expect(torn == 0).Should(BeTrue())
+		Expect(first.A == 0 && first.B == 0).Should(BeTrue())
+		Expect(c.GetMixedConfig().(*PairConf).B == 500).Should(BeTrue())
+	})
+
+	It("register same name in parallel", func() {
+		c := NewConfigIns()
+
+		var succeed int32
+		wg := sync.WaitGroup{}
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if c.RegisterFileWithName("app", "mixed.yaml", &MongoConf{}) == nil {
+					atomic.AddInt32(&succeed, 1)
+				}
+			}()
+		}
+
+		wg.Wait()
+		Expect(succeed == 1).Should(BeTrue())
+	})
+
+	It("nacos updates in parallel", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "a: -1\nb: -1"})
+		defer keepCodec("slow")()
+		RegisterCodec("slow", slowCodec{})
+		Expect(c.RegisterNacosStruct(dataID, group, &PairConf{}, primitive.WithFormat("slow"))).Should(Succeed())
+
+		// 与nacos客户端一致，在不同的协程中触发更新；每次更新的old为上一次更新的new，不会重复
+		var (
+			mutex sync.Mutex
+			olds  = map[interface{}]bool{}
+		)
+
+		c.Subscribe(defaultName, func(old, new interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			Expect(olds[old]).Should(BeFalse())
+			olds[old] = true
+		})
+
+		wg := sync.WaitGroup{}
+		for i := 1; i <= 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				c.onChange("", group, dataID, fmt.Sprintf("a: %d\nb: %d", i, i))
+			}(i)
+		}
+
+		wg.Wait()
+		Expect(len(olds) == 20).Should(BeTrue())
+	})
+})
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	. "config/primitive"
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+// fillBackoff 懒注册时后台拉取配置的默认重试策略
+var fillBackoff = Backoff{Initial: time.Second, Max: 30 * time.Second, Jitter: 0.2}
+
+// delay 第n次（从0开始）重试前的等待时间
+func delay(b Backoff, n int) time.Duration {
+	d := b.Initial
+	for i := 0; i < n && (b.Max <= 0 || d < b.Max); i++ {
+		d *= 2
+	}
+
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+
+	if b.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * b.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// retry 按照重试策略执行f，直到成功、遇到不可重试的错误或达到最大次数；done关闭时停止重试
+func retry(b Backoff, done <-chan struct{}, f func() error) error {
+	for n := 0; ; n++ {
+		err := f()
+		if err == nil || !retryable(err) || n+1 >= b.Attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay(b, n)):
+		case <-done:
+			return err
+		}
+	}
+}
+
+// retryable 网络不可用、域名解析失败时可以重试，鉴权失败及无法识别的错误不重试
+func retryable(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrDNS)
+}
+
+// fetch 拉取nacos配置，失败时按照实例的重试策略重试
+//  服务端不可用时GetConfig会返回本地缓存且没有错误，因此先通过reachable确认服务端可用
+func (c *configIns) fetch(client INacosClient, dataID, group string) (string, error) {
+	var content string
+	err := retry(c.options.Retry, c.done, func() error {
+		if err := c.reachable(client); err != nil {
+			return err
+		}
+
+		var err error
+		content, err = client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+		return c.getFailed(connectError(err))
+	})
+
+	return content, err
+}
+
+// readiness 懒注册的配置是否已从nacos获取到内容
+type readiness struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func newReadiness(ready bool) *readiness {
+	r := &readiness{ch: make(chan struct{})}
+	if ready {
+		r.done()
+	}
+
+	return r
+}
+
+// done 标记为就绪
+func (r *readiness) done() {
+	r.once.Do(func() {
+		close(r.ch)
+	})
+}
+
+// fill 懒注册时在后台拉取nacos配置，拉取成功后按照配置变化的流程更新；就绪、注销或实例关闭后退出
+func (c *configIns) fill(r *readiness, dataID, group string, registered func(s *snapshot) bool) {
+	b := c.options.Retry
+	if b.Initial <= 0 {
+		b = fillBackoff
+	}
+
+	go func() {
+		for n := 0; ; n++ {
+			select {
+			case <-r.ch:
+				return
+			case <-c.done:
+				return
+			case <-time.After(delay(b, n)):
+			}
+
+			s := c.load()
+			if !registered(s) {
+				return
+			}
+
+			// 与fetch一致，服务端不可用时不使用GetConfig返回的本地缓存
+			if c.reachable(s.client) != nil {
+				continue
+			}
+
+			content, err := s.client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+			if c.getFailed(connectError(err)) != nil || content == "" {
+				continue
+			}
+
+			c.onChange(s.namespace, group, dataID, content)
+		}
+	}()
+}
+
+// Ready 等待name对应的nacos、混合模式配置从nacos获取到内容，未使用WithLazy注册的配置及文件、分层模式立即返回
+//  ctx结束时返回ctx.Err()，name未注册时返回ErrNotRegistered
+func (c *configIns) Ready(ctx context.Context, name string) error {
+	s := c.load()
+
+	var rs []*readiness
+	if nc, exist := s.nacos[name]; exist {
+		rs = append(rs, nc.ready)
+	}
+
+	if mc, exist := s.mixed[name]; exist {
+		rs = append(rs, mc.ready)
+	}
+
+	_, inFile := s.files[name]
+	_, inLayered := s.layered[name]
+	if !inFile && !inLayered && len(rs) == 0 {
+		return ErrNotRegistered
+	}
+
+	for _, r := range rs {
+		select {
+		case <-r.ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"config/configtest"
+	"config/primitive"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// flakyClient 前failures次GetConfig返回网络错误的nacos客户端
+type flakyClient struct {
+	*configtest.Client
+	failures int32
+	calls    int32
+}
+
+func (fc *flakyClient) GetConfig(param vo.ConfigParam) (string, error) {
+	if atomic.AddInt32(&fc.calls, 1) <= atomic.LoadInt32(&fc.failures) {
+		return "", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+
+	return fc.Client.GetConfig(param)
+}
+
+var _ = Describe("Retry", func() {
+	It("delay", func() {
+		b := primitive.Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+		Expect(delay(b, 0) == 100*time.Millisecond).Should(BeTrue())
+		Expect(delay(b, 3) == 800*time.Millisecond).Should(BeTrue())
+		Expect(delay(b, 100) == time.Second).Should(BeTrue())
+
+		b.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			d := delay(b, 1)
+			Expect(d >= 100*time.Millisecond && d <= 300*time.Millisecond).Should(BeTrue())
+		}
+	})
+
+	It("retry", func() {
+		b := primitive.Backoff{Attempts: 3, Initial: time.Millisecond}
+		netErr := connectError(&net.OpError{Op: "dial", Err: errors.New("i/o timeout")})
+
+		count := 0
+		err := retry(b, nil, func() error {
+			count++
+			return netErr
+		})
+		Expect(err == netErr).Should(BeTrue())
+		Expect(count == 3).Should(BeTrue())
+
+		// 鉴权失败不重试
+		count = 0
+		retry(b, nil, func() error {
+			count++
+			return connectError(errors.New("get config forbidden"))
+		})
+		Expect(count == 1).Should(BeTrue())
+
+		count = 0
+		err = retry(b, nil, func() error {
+			count++
+			if count < 2 {
+				return netErr
+			}
+			return nil
+		})
+		Expect(err).Should(Succeed())
+		Expect(count == 2).Should(BeTrue())
+	})
+
+	It("register with retry", func() {
+		client := &flakyClient{Client: configtest.NewClient(), failures: 2}
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+		c := NewConfigIns(primitive.WithNacosClient(client, ""))
+		err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+		Expect(errors.Is(err, primitive.ErrNetwork)).Should(BeTrue())
+
+		atomic.StoreInt32(&client.calls, 0)
+		c = NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithRetry(primitive.Backoff{Attempts: 3, Initial: time.Millisecond}))
+		Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+		Expect(c.GetNacosConfig().(*MongoConf).Host == "a").Should(BeTrue())
+	})
+
+	Context("lazy", func() {
+		It("nacos fallback", func() {
+			c, client := newTestIns()
+
+			err := c.RegisterNacosStruct(dataID, group, &MongoConf{})
+			Expect(err).Should(Equal(primitive.ErrNotExistConfig))
+
+			err = c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithLazy(&MongoConf{Host: "fallback"}))
+			Expect(err).Should(Succeed())
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "fallback").Should(BeTrue())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			Expect(c.Ready(ctx, defaultName)).Should(Equal(context.DeadlineExceeded))
+
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+			Expect(c.Ready(context.Background(), defaultName)).Should(Succeed())
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "a").Should(BeTrue())
+		})
+
+		It("default fallback & type check", func() {
+			c, _ := newTestIns()
+
+			err := c.RegisterNacosStructWithName("a", dataID, group, &ValidateRedisConf{}, primitive.WithLazy(nil))
+			Expect(err).Should(Succeed())
+			Expect(c.GetNacosConfigByName("a").(*ValidateRedisConf).Port == 6379).Should(BeTrue())
+
+			err = c.RegisterNacosStructWithName("b", "redis", group, &ValidateRedisConf{}, primitive.WithLazy(&MongoConf{}))
+			Expect(err).Should(Equal(primitive.ErrTypeMismatch))
+		})
+
+		It("background fill", func() {
+			client := &flakyClient{Client: configtest.NewClient(), failures: 3}
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+			c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithRetry(primitive.Backoff{Initial: time.Millisecond}))
+			Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{}, primitive.WithLazy(nil))).Should(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			Expect(c.Ready(ctx, defaultName)).Should(Succeed())
+			Expect(c.GetMixedConfig().(*MongoConf).Host == "a").Should(BeTrue())
+			Expect(c.Health().Syncs[0].LastSync.IsZero()).Should(BeFalse())
+		})
+
+		It("fill after server recovered", func() {
+			client := newCachedClient()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: cached"})
+			client.GetConfig(vo.ConfigParam{DataId: dataID, Group: group})
+			atomic.StoreInt32(&client.down, 1)
+
+			c := NewConfigIns(primitive.WithNacosClient(client, ""), primitive.WithRetry(primitive.Backoff{Initial: time.Millisecond}))
+			defer c.Close(context.Background())
+			Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{}, primitive.WithLazy(&MongoConf{Host: "fallback"}))).Should(Succeed())
+
+			// 服务端不可用时不使用客户端的缓存，也不标记为就绪
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(c.Ready(ctx, defaultName)).Should(Equal(context.DeadlineExceeded))
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "fallback").Should(BeTrue())
+			Expect(c.Health().Syncs[0].LastSync.IsZero()).Should(BeTrue())
+
+			atomic.StoreInt32(&client.down, 0)
+			Expect(c.Ready(context.Background(), defaultName)).Should(Succeed())
+			Expect(c.GetNacosConfig().(*MongoConf).Host == "cached").Should(BeTrue())
+		})
+
+		It("ready", func() {
+			c, client := newTestIns()
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+
+			Expect(c.RegisterFile("mixed.yaml", &MongoConf{})).Should(Succeed())
+			Expect(c.RegisterNacosStruct(dataID, group, &MongoConf{})).Should(Succeed())
+			Expect(c.Ready(context.Background(), defaultName)).Should(Succeed())
+			Expect(c.Ready(context.Background(), "unknown")).Should(Equal(primitive.ErrNotRegistered))
+		})
+	})
+
+	It("lazy dial", func() {
+		dir, err := ioutil.TempDir("", "nacos")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		opts := []primitive.ConfigOption{
+			primitive.WithLogDir(dir),
+			primitive.WithCacheDir(dir),
+		}
+
+		c := NewConfigIns(opts...)
+		Expect(errors.Is(c.DailNacos("nacos.invalid:8848", ""), primitive.ErrDNS)).Should(BeTrue())
+
+		c = NewConfigIns(append(opts, primitive.WithLazyDial())...)
+		Expect(c.DailNacos("nacos.invalid:8848", "")).Should(Succeed())
+		Expect(c.load().client).ShouldNot(BeNil())
+		Expect(c.health.state == primitive.Unreachable).Should(BeTrue())
+	})
+})
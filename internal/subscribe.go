@@ -0,0 +1,89 @@
+package internal
+
+import (
+	. "config/primitive"
+	"context"
+	"sync"
+)
+
+// watchBufferSize Watch返回的channel缓冲大小，消费不及时时丢弃最早的事件
+const watchBufferSize = 16
+
+type subscriber struct {
+	name string
+	h    ChangeHandler
+}
+
+// Subscribe 订阅name对应配置的变化，文件、nacos、混合模式更新成功后触发，返回取消订阅的函数
+//  回调在锁外执行，可以在回调中读取配置；old与new为不同的对象，均不可修改
+func (c *configIns) Subscribe(name string, h ChangeHandler) func() {
+	sub := &subscriber{name: name, h: h}
+
+	c.mutex.Lock()
+	c.subscribers[sub] = struct{}{}
+	c.mutex.Unlock()
+
+	return func() {
+		c.mutex.Lock()
+		delete(c.subscribers, sub)
+		c.mutex.Unlock()
+	}
+}
+
+// Watch 通过channel接收name对应配置的变化，ctx结束后取消订阅并关闭channel
+func (c *configIns) Watch(ctx context.Context, name string) <-chan Change {
+	var mutex sync.Mutex
+	closed := false
+	ch := make(chan Change, watchBufferSize)
+
+	cancel := c.Subscribe(name, func(old, new interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if closed {
+			return
+		}
+
+		change := Change{Name: name, Old: old, New: new}
+		for {
+			select {
+			case ch <- change:
+				return
+			default:
+				// channel已满，丢弃最早的事件
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		cancel()
+
+		mutex.Lock()
+		closed = true
+		close(ch)
+		mutex.Unlock()
+	}()
+
+	return ch
+}
+
+// notify 通知name对应配置的订阅者
+func (c *configIns) notify(name string, old, new interface{}) {
+	c.mutex.RLock()
+	handlers := []ChangeHandler{}
+	for sub := range c.subscribers {
+		if sub.name == name {
+			handlers = append(handlers, sub.h)
+		}
+	}
+	c.mutex.RUnlock()
+
+	for _, h := range handlers {
+		h(old, new)
+	}
+}
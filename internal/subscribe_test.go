@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"config/primitive"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subscribe", func() {
+	It("file mode", func() {
+		dir, err := ioutil.TempDir("", "config")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "mongo.yaml")
+		Expect(ioutil.WriteFile(file, []byte("host: mongodb://localhost:27017/monkey"), 0644)).Should(Succeed())
+
+		c := NewConfigIns()
+		Expect(c.RegisterFile(file, &MongoConf{}, primitive.WithWatch())).Should(Succeed())
+
+		changes := make(chan [2]string, 10)
+		c.Subscribe(defaultName, func(old, new interface{}) {
+			// 回调中可以读取配置
+			Expect(c.GetFileConfig() == new).Should(BeTrue())
+			changes <- [2]string{old.(*MongoConf).Host, new.(*MongoConf).Host}
+		})
+
+		Expect(ioutil.WriteFile(file, []byte("host: mongodb://server:27017/monkey"), 0644)).Should(Succeed())
+		Eventually(changes).Should(Receive(Equal([2]string{"mongodb://localhost:27017/monkey", "mongodb://server:27017/monkey"})))
+	})
+
+	It("nacos mode", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		Expect(c.RegisterNacosStructWithName("app", dataID, group, &MongoConf{})).Should(Succeed())
+
+		var hosts []string
+		cancel := c.Subscribe("app", func(old, new interface{}) {
+			hosts = append(hosts, old.(*MongoConf).Host, new.(*MongoConf).Host)
+		})
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(hosts).Should(Equal([]string{"a", "b"}))
+
+		// 更新失败不会触发
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: ["})
+		Expect(hosts).Should(HaveLen(2))
+
+		cancel()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: c"})
+		Expect(hosts).Should(HaveLen(2))
+	})
+
+	It("mixed mode", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		Expect(c.RegisterMixed("mixed.yaml", dataID, group, &MongoConf{})).Should(Succeed())
+
+		count := 0
+		c.Subscribe(defaultName, func(old, new interface{}) {
+			Expect(new.(*MongoConf).Host == "b").Should(BeTrue())
+			count++
+		})
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+		Expect(count == 1).Should(BeTrue())
+	})
+
+	It("watch", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: a"})
+		Expect(c.RegisterNacos(dataID, group)).Should(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := c.Watch(ctx, defaultName)
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "host: b"})
+
+		var change primitive.Change
+		Eventually(ch).Should(Receive(&change))
+		Expect(change.Name == defaultName).Should(BeTrue())
+		Expect(change.Old == "host: a").Should(BeTrue())
+		Expect(change.New == "host: b").Should(BeTrue())
+
+		// 消费不及时时保留最新的事件
+		for i := 0; i < watchBufferSize*2; i++ {
+			client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: fmt.Sprintf("host: c%d", i)})
+		}
+		Expect(len(ch) == watchBufferSize).Should(BeTrue())
+
+		cancel()
+		Eventually(func() bool {
+			for range ch {
+			}
+			return true
+		}).Should(BeTrue())
+	})
+})
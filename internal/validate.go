@@ -0,0 +1,198 @@
+package internal
+
+import (
+	. "config/primitive"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyDefaults 根据default tag为字段设置默认值，在解析配置前调用，配置中存在的字段会覆盖默认值
+//  nil指针及map中的对象在解析时才会创建，不会设置默认值
+func applyDefaults(rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct || isScalar(rv.Type()) {
+		return nil
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			if err := applyDefaults(rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := setValue(rv.Field(i), def); err != nil {
+			return fmt.Errorf("default of %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validate 根据validate tag校验配置，返回*ValidationError，包含所有未通过校验的字段
+//  支持的规则：required（非零值）、min=n、max=n（数值比较大小，string/slice/map比较长度）、oneof=a b c
+func validate(v interface{}) error {
+	verr := &ValidationError{}
+	validateValue(reflect.ValueOf(v), "", verr)
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// validateValue 遍历对象，path为当前对象的路径
+func validateValue(rv reflect.Value, path string, verr *ValidationError) {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !rv.IsNil() {
+			validateValue(rv.Elem(), path, verr)
+		}
+
+	case reflect.Struct:
+		if isScalar(rv.Type()) {
+			return
+		}
+
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			key, inline := yamlKey(field)
+			child := path
+			if !inline {
+				if key == "" {
+					continue
+				}
+				child = joinPath(path, key)
+			}
+
+			fv := rv.Field(i)
+			if tag := field.Tag.Get("validate"); tag != "" {
+				checkRules(fv, child, tag, verr)
+			}
+
+			validateValue(fv, child, verr)
+		}
+
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			validateValue(rv.MapIndex(k), joinPath(path, fmt.Sprint(k.Interface())), verr)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			validateValue(rv.Index(i), fmt.Sprintf("%s[%d]", path, i), verr)
+		}
+	}
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// checkRules 校验单个字段
+func checkRules(fv reflect.Value, path, tag string, verr *ValidationError) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg := rule, ""
+		if idx := strings.Index(rule, "="); idx >= 0 {
+			name, arg = rule[:idx], rule[idx+1:]
+		}
+
+		if msg := checkRule(fv, name, arg); msg != "" {
+			verr.Fields = append(verr.Fields, FieldError{Path: path, Rule: rule, Message: msg})
+		}
+	}
+}
+
+// checkRule 校验单条规则，通过时返回空字符串
+func checkRule(fv reflect.Value, name, arg string) string {
+	if name == "required" {
+		if fv.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+
+	// 未设置的指针字段只校验required
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return ""
+		}
+		fv = fv.Elem()
+	}
+
+	switch name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %s=%s", name, arg)
+		}
+
+		n, ok := measure(fv)
+		if !ok {
+			return fmt.Sprintf("rule %s not supported for %s", name, fv.Type())
+		}
+
+		if name == "min" && n < limit {
+			return fmt.Sprintf("must be at least %s", arg)
+		}
+
+		if name == "max" && n > limit {
+			return fmt.Sprintf("must be at most %s", arg)
+		}
+
+	case "oneof":
+		value := fmt.Sprint(fv.Interface())
+		for _, item := range strings.Fields(arg) {
+			if item == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", arg, value)
+
+	default:
+		return fmt.Sprintf("unknown rule %s", name)
+	}
+
+	return ""
+}
+
+// measure 获取用于min/max比较的值：数值为本身，string/slice/map为长度
+func measure(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), true
+	}
+
+	return 0, false
+}
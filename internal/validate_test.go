@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"config/primitive"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ValidateRedisConf struct {
+	Host    string        `yaml:"host" validate:"required"`
+	Port    int           `yaml:"port" default:"6379" validate:"min=1,max=65535"`
+	Timeout time.Duration `yaml:"timeout" default:"3s"`
+}
+
+type ValidateConfig struct {
+	LogLevel string            `yaml:"log_level" default:"info" validate:"oneof=debug info warn error"`
+	Hosts    []string          `yaml:"hosts" default:"a,b" validate:"min=1"`
+	Redis    ValidateRedisConf `yaml:"redis"`
+
+	Mongo map[string]*ValidateRedisConf `yaml:"mongo"`
+}
+
+var _ = Describe("Validate", func() {
+	It("defaults", func() {
+		conf, err := copyAndUnmarshal(yamlCodec{}, []byte("redis:\n  host: 127.0.0.1"), &ValidateConfig{})
+		Expect(err).Should(Succeed())
+
+		vc := conf.(*ValidateConfig)
+		Expect(vc.LogLevel == "info").Should(BeTrue())
+		Expect(vc.Hosts).Should(Equal([]string{"a", "b"}))
+		Expect(vc.Redis.Host == "127.0.0.1").Should(BeTrue())
+		Expect(vc.Redis.Port == 6379).Should(BeTrue())
+		Expect(vc.Redis.Timeout == 3*time.Second).Should(BeTrue())
+		Expect(validate(vc)).Should(Succeed())
+
+		// 只有默认值时视为空配置
+		_, err = copyAndUnmarshal(yamlCodec{}, []byte(""), &ValidateConfig{})
+		Expect(err).Should(Equal(primitive.ErrEmptyConfig))
+
+		// 配置的值与默认值相同时不是空配置
+		conf, err = copyAndUnmarshal(yamlCodec{}, []byte("port: 6379"), &ValidateRedisConf{})
+		Expect(err).Should(Succeed())
+		Expect(conf.(*ValidateRedisConf).Port == 6379 && conf.(*ValidateRedisConf).Timeout == 3*time.Second).Should(BeTrue())
+	})
+
+	It("field errors", func() {
+		conf := &ValidateConfig{
+			LogLevel: "trace",
+			Redis:    ValidateRedisConf{Port: 70000},
+			Mongo:    map[string]*ValidateRedisConf{"monkey": {Port: 1}},
+		}
+
+		err := validate(conf)
+		Expect(errors.Is(err, primitive.ErrInvalidConfig)).Should(BeTrue())
+
+		verr := &primitive.ValidationError{}
+		Expect(errors.As(err, &verr)).Should(BeTrue())
+
+		paths := map[string]string{}
+		for _, f := range verr.Fields {
+			paths[f.Path] = f.Rule
+		}
+
+		Expect(paths).Should(Equal(map[string]string{
+			"log_level":         "oneof=debug info warn error",
+			"hosts":             "min=1",
+			"redis.host":        "required",
+			"redis.port":        "max=65535",
+			"mongo.monkey.host": "required",
+		}))
+	})
+
+	It("register & reload", func() {
+		dir, err := ioutil.TempDir("", "config")
+		Expect(err).Should(Succeed())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "app.yaml")
+		Expect(ioutil.WriteFile(file, []byte("log_level: debug"), 0644)).Should(Succeed())
+
+		c := NewConfigIns()
+		err = c.RegisterFile(file, &ValidateConfig{})
+		Expect(errors.Is(err, primitive.ErrInvalidConfig)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("redis.host"))
+
+		reported := make(chan error, 10)
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			reported <- err
+		})
+
+		Expect(ioutil.WriteFile(file, []byte("redis:\n  host: 127.0.0.1"), 0644)).Should(Succeed())
+		err = c.RegisterFile(file, &ValidateConfig{}, primitive.WithWatch())
+		Expect(err).Should(Succeed())
+
+		Expect(ioutil.WriteFile(file, []byte("redis:\n  host: 127.0.0.1\n  port: 0"), 0644)).Should(Succeed())
+
+		var reloadErr error
+		Eventually(reported).Should(Receive(&reloadErr))
+		Expect(errors.Is(reloadErr, primitive.ErrInvalidConfig)).Should(BeTrue())
+		Expect(c.GetFileConfig().(*ValidateConfig).Redis.Port == 6379).Should(BeTrue())
+	})
+
+	It("nacos reload", func() {
+		c, client := newTestIns()
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "redis:\n  host: 127.0.0.1"})
+
+		var reported error
+		c.OnError(func(name string, source primitive.Flag, err error) {
+			reported = err
+		})
+
+		err := c.RegisterNacosStruct(dataID, group, &ValidateConfig{})
+		Expect(err).Should(Succeed())
+
+		client.PublishConfig(vo.ConfigParam{DataId: dataID, Group: group, Content: "log_level: trace\nredis:\n  host: 127.0.0.1"})
+		Expect(errors.Is(reported, primitive.ErrInvalidConfig)).Should(BeTrue())
+		Expect(c.GetNacosConfig().(*ValidateConfig).LogLevel == "info").Should(BeTrue())
+	})
+})
@@ -1,8 +1,11 @@
 package primitive
 
 import (
+	"time"
+
 	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/common/logger"
 )
 
 var (
@@ -15,11 +18,13 @@ var (
 	WithUserName     = constant.WithUsername
 	WithPassword     = constant.WithPassword
 	WithLogLevel     = constant.WithLogLevel
+	WithLogStdout    = constant.WithLogStdout // 日志同时输出到标准输出，默认不输出
 )
 
 type (
 	ClientOption = constant.ClientOption
 	INacosClient = config_client.IConfigClient
+	Logger       = logger.Logger
 )
 
 type Flag uint8
@@ -29,9 +34,171 @@ const (
 	OnlyFile              // 文件模式
 	OnlyNacos             // nacos模式
 	Mixed                 // 混合模式
+	Layered               // 分层模式
+	Defaults              // 分层模式中的默认值层
+	EnvVars               // 分层模式中的环境变量层
+	CmdFlags              // 分层模式中的命令行参数层
 )
 
+func (f Flag) String() string {
+	switch f {
+	case OnlyFile:
+		return "file"
+	case OnlyNacos:
+		return "nacos"
+	case Mixed:
+		return "mixed"
+	case Layered:
+		return "layered"
+	case Defaults:
+		return "defaults"
+	case EnvVars:
+		return "env"
+	case CmdFlags:
+		return "flags"
+	default:
+		return "unknown"
+	}
+}
+
 type IMixedConfig interface {
 	UpdateAfterRegister()                                       // 注册成功过，调用该函数进行更新操作
 	OnNacosChanged(namespace, group, dataId, data string) error // nacos有变更时触发该函数
 }
+
+// NacosChangedHook 混合模式的配置实现该接口时，nacos内容由OnNacosChanged自行解析，不使用内置的合并
+type NacosChangedHook interface {
+	OnNacosChanged(namespace, group, dataId, data string) error
+}
+
+// AfterRegisterHook 混合模式的配置实现该接口时，注册成功后调用UpdateAfterRegister
+type AfterRegisterHook interface {
+	UpdateAfterRegister()
+}
+
+// MergeStrategy 混合模式中nacos内容与配置文件内容的合并方式
+type MergeStrategy uint8
+
+const (
+	MergeOverlay MergeStrategy = iota // nacos内容深度合并到配置文件的内容上，map逐个key合并，其他类型整体覆盖
+	MergeReplace                      // 只使用nacos内容，nacos内容为空时使用配置文件
+	MergeField                        // 按照字段的merge tag合并：overlay（默认）、replace（整体替换）、ignore（只使用配置文件）
+)
+
+// String 合并方式的名称
+func (m MergeStrategy) String() string {
+	switch m {
+	case MergeOverlay:
+		return "overlay"
+	case MergeReplace:
+		return "replace"
+	case MergeField:
+		return "field"
+	default:
+		return "unknown"
+	}
+}
+
+// ClientCloser DailNacos创建的nacos客户端实现该接口时，Close会调用CloseClient释放客户端
+//  nacos-sdk-go v1.1.4的客户端没有实现该接口
+type ClientCloser interface {
+	CloseClient()
+}
+
+// Codec 配置内容的编解码器，按照文件扩展名或nacos dataID的格式选择
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// ChangeHandler 配置更新成功后的回调，old为更新前的配置，new为更新后的配置
+type ChangeHandler func(old, new interface{})
+
+// Change 配置更新事件
+type Change struct {
+	Name string
+	Old  interface{}
+	New  interface{}
+}
+
+// Backoff 失败重试策略，等待时间按指数增长并加入随机抖动
+type Backoff struct {
+	Attempts int           // 最多尝试的次数，<=1时不重试
+	Initial  time.Duration // 第一次重试前的等待时间
+	Max      time.Duration // 等待时间的上限，为0时不限制
+	Jitter   float64       // 随机抖动的比例，取值0~1，e.g. 0.2表示等待时间在±20%内浮动
+}
+
+// ConnState nacos连接状态
+type ConnState uint8
+
+const (
+	NotConnected ConnState = iota // 未设置nacos客户端
+	Connected                     // 探测成功
+	Unreachable                   // 探测失败
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Unreachable:
+		return "unreachable"
+	default:
+		return "not connected"
+	}
+}
+
+// Health nacos连接的健康状态
+type Health struct {
+	State     ConnState    // 连接状态
+	Err       error        // 探测失败的原因，*ConnectError
+	LastCheck time.Time    // 最近一次探测的时间
+	Syncs     []SyncStatus // 已注册的dataID和group的同步状态
+}
+
+// SyncStatus dataID和group的同步状态
+type SyncStatus struct {
+	DataID   string
+	Group    string
+	LastSync time.Time // 最近一次成功拉取或收到变更的时间
+
+	Stale      bool      // 使用本地快照启动，尚未从服务端同步
+	SnapshotAt time.Time // Stale时快照从服务端获取的时间
+}
+
+// UpdateFailure 配置热更新失败的记录，失败时保留上一次有效的配置
+type UpdateFailure struct {
+	Name     string
+	Source   Flag      // 配置的模式，e.g. OnlyFile、OnlyNacos、Mixed、Layered
+	Failures uint64    // 累计失败次数
+	Err      error     // 最近一次失败的原因
+	Payload  string    // 最近一次导致失败的内容（nacos内容、文件内容），读取失败时为空
+	At       time.Time // 最近一次失败的时间
+}
+
+// Origin 配置项的一个来源
+type Origin struct {
+	Source Flag   // OnlyFile、OnlyNacos、Defaults、EnvVars、CmdFlags之一
+	Layer  string // 分层模式中层的名称
+
+	File   string // Source为OnlyFile时的文件
+	Line   int    // 文件、nacos内容中的行号，从1开始，无法定位（e.g. toml、env格式）时为0
+	DataID string // Source为OnlyNacos时的dataID
+	Group  string // Source为OnlyNacos时的group
+	Key    string // 环境变量或命令行参数的名称
+
+	Version string      // 文件、nacos内容的md5
+	Value   interface{} // 该来源中的值
+}
+
+// Explanation 配置项的生效值及其来源
+type Explanation struct {
+	Name  string
+	Path  string
+	Flag  Flag        // 配置的模式，与GetConfigWithFlagByName一致
+	Value interface{} // 生效的值
+
+	Origin     Origin   // 生效的来源；所有来源都不包含该路径时为Defaults，值来自default tag或零值
+	Overridden []Origin // 被覆盖的低优先级来源，按照优先级从高到低排列
+}
@@ -1,6 +1,9 @@
 package primitive
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrAlreadyRegister               = errors.New("name had been registered")
@@ -11,4 +14,72 @@ var (
 	ErrEmptyConfig                   = errors.New("empty config object")
 	ErrConnectFailed                 = errors.New("connect nacos server failed")
 	ErrNotExistConfig                = errors.New("not exist configure on nacos server")
+	ErrUnknownFormat                 = errors.New("unknown config format")
+	ErrProfileNotFound               = errors.New("profile not found in envs")
+	ErrInvalidConfig                 = errors.New("invalid config")
+	ErrNotRegistered                 = errors.New("name not registered")
+	ErrPathNotFound                  = errors.New("path not found in config")
+	ErrTypeMismatch                  = errors.New("value type mismatch")
+	ErrClosed                        = errors.New("config has been closed")
+	ErrAuthFailed                    = errors.New("nacos auth failed")
+	ErrNetwork                       = errors.New("nacos network unreachable")
+	ErrDNS                           = errors.New("nacos address resolve failed")
+	ErrEmptyDataIDOrGroup            = errors.New("dataID and group can not be empty")
+	ErrEmptyLayers                   = errors.New("layers can not be empty")
+	ErrNotPublishable                = errors.New("config can not be published to nacos")
+	ErrPublishFailed                 = errors.New("publish config to nacos failed")
+	ErrConflict                      = errors.New("nacos content changed since last seen")
 )
+
+// ConnectError 连接nacos服务端失败
+//  errors.Is(err, ErrConnectFailed)为true，Kind为ErrAuthFailed、ErrNetwork、ErrDNS之一，无法识别时为nil
+type ConnectError struct {
+	Kind error
+	Err  error // 原始错误
+}
+
+func (e *ConnectError) Error() string {
+	if e.Kind == nil {
+		return ErrConnectFailed.Error() + ": " + e.Err.Error()
+	}
+
+	return ErrConnectFailed.Error() + ": " + e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConnectError) Is(target error) bool {
+	return target == ErrConnectFailed || (e.Kind != nil && target == e.Kind)
+}
+
+// FieldError 字段校验失败的信息
+type FieldError struct {
+	Path    string // 字段路径，由yaml key组成，e.g. redis.host
+	Rule    string // 未通过的规则，e.g. required、min=1
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError 配置校验失败，包含所有未通过校验的字段
+//  errors.Is(err, ErrInvalidConfig)为true
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+
+	return ErrInvalidConfig.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
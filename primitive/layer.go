@@ -0,0 +1,59 @@
+package primitive
+
+import "flag"
+
+// Layer 分层配置中的一层，由DefaultsLayer、FileLayer、NacosLayer等函数创建
+//  注册时按照传入的顺序深度合并，map逐个key合并，其他类型（包括slice）整体覆盖，后面的层优先级更高
+type Layer struct {
+	Name   string // 层的名称，用于记录配置项的来源，e.g. file:app.yaml
+	Source Flag   // 层的来源：Defaults、OnlyFile、OnlyNacos、EnvVars、CmdFlags
+
+	Value    interface{}   // Defaults：默认值对象
+	File     string        // OnlyFile：配置文件
+	Optional bool          // OnlyFile：文件不存在时跳过该层
+	DataID   string        // OnlyNacos
+	Group    string        // OnlyNacos
+	Prefix   string        // EnvVars：环境变量名称的前缀
+	FlagSet  *flag.FlagSet // CmdFlags：只使用命令行中设置过的参数
+}
+
+// DefaultsLayer 默认值层，v为结构体或其指针，通常作为第一层
+//  零值字段不参与合并，此时使用default tag的值
+func DefaultsLayer(v interface{}) Layer {
+	return Layer{Name: "defaults", Source: Defaults, Value: v}
+}
+
+// FileLayer 配置文件层，格式根据扩展名识别，文件不存在时注册失败
+//  注册时指定WithWatch()，文件变更后重新合并
+func FileLayer(file string) Layer {
+	return Layer{Name: "file:" + file, Source: OnlyFile, File: file}
+}
+
+// OptionalFileLayer 可选的配置文件层，文件不存在时跳过，e.g. 各环境单独的app.prod.yaml
+func OptionalFileLayer(file string) Layer {
+	l := FileLayer(file)
+	l.Optional = true
+	return l
+}
+
+// NacosLayer nacos配置层，格式由WithFormat指定或根据dataID的扩展名识别，配置不存在时为空
+//  配置变化后重新合并
+func NacosLayer(dataID, group string) Layer {
+	return Layer{Name: "nacos:" + group + "/" + dataID, Source: OnlyNacos, DataID: dataID, Group: group}
+}
+
+// EnvLayer 环境变量层，环境变量名称的规则与WithEnv一致，e.g. prefix为APP时，redis.port对应APP_REDIS_PORT
+//  只处理结构体中的字段，map中的key不会生成环境变量名称
+func EnvLayer(prefix string) Layer {
+	return Layer{Name: "env", Source: EnvVars, Prefix: prefix}
+}
+
+// FlagsLayer 命令行参数层，参数名称为yaml key组成的路径，e.g. -redis.port=6379
+//  只使用命令行中设置过的参数，fs为nil时使用flag.CommandLine，需要在注册前调用Parse
+func FlagsLayer(fs *flag.FlagSet) Layer {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	return Layer{Name: "flags", Source: CmdFlags, FlagSet: fs}
+}
@@ -0,0 +1,258 @@
+package primitive
+
+// DefaultProfileEnv 默认读取环境名称的环境变量
+const DefaultProfileEnv = "CUR_ENV"
+
+// 探测nacos服务端时默认查询的dataID和group
+const (
+	DefaultProbeDataID = "probe"
+	DefaultProbeGroup  = "DEFAULT_GROUP"
+)
+
+// RegisterOption 注册配置时的可选项
+type RegisterOption func(*RegisterOptions)
+
+// RegisterOptions 注册配置的选项集合
+type RegisterOptions struct {
+	Watch  bool   // 文件模式下监听文件变化，文件变更后热更新配置
+	Format string // nacos配置内容的格式，e.g. yaml、json、toml、env
+
+	UseProfile bool   // 按照cur_env/envs的结构只解析当前环境的配置
+	Profile    string // 指定的环境名称
+	ProfileEnv string // 读取环境名称的环境变量
+
+	Env       bool   // 解析配置后使用环境变量覆盖字段
+	EnvPrefix string // 根据yaml tag生成环境变量名称时使用的前缀
+
+	SharedListener bool // dataID和group已被当前实例注册时，共享已有的监听
+
+	Lazy     bool        // 首次拉取nacos配置失败时仍注册成功，服务端可用后再填充
+	Fallback interface{} // Lazy时nacos模式的初始值
+
+	DeepCopy bool // 读取配置时返回深拷贝
+
+	Merge MergeStrategy // 混合模式中nacos内容与配置文件内容的合并方式
+}
+
+// ErrorHandler 配置热更新失败时的回调，source为配置来源
+type ErrorHandler func(name string, source Flag, err error)
+
+// WithWatch 监听配置文件变化（兼容k8s ConfigMap通过symlink替换文件的方式）
+//  文件内容非法时，保留上一次有效的配置，并通过OnError注册的回调通知
+func WithWatch() RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Watch = true
+	}
+}
+
+// WithFormat 指定nacos配置内容的格式，未指定时根据dataID的扩展名识别，无法识别时按照yaml处理
+func WithFormat(format string) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Format = format
+	}
+}
+
+// WithProfile 配置按照cur_env/envs的结构区分环境，只将当前环境的配置解析到注册的对象中
+//  环境名称的优先级：profile参数 -> 环境变量（默认为CUR_ENV，可通过WithProfileEnv修改） -> 配置中的cur_env
+func WithProfile(profile string) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.UseProfile = true
+		o.Profile = profile
+	}
+}
+
+// WithProfileEnv 指定读取环境名称的环境变量，同时开启WithProfile
+func WithProfileEnv(name string) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.UseProfile = true
+		o.ProfileEnv = name
+	}
+}
+
+// WithEnv 解析配置后使用环境变量覆盖字段，文件、nacos、混合模式均在每次加载后生效
+//  字段的env tag为环境变量名称（"-"表示忽略）；prefix不为空时，没有env tag的字段根据yaml tag生成名称，
+//  e.g. prefix为APP时，redis.port对应APP_REDIS_PORT；支持基础类型、time.Duration、逗号分隔的slice
+func WithEnv(prefix string) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Env = true
+		o.EnvPrefix = prefix
+	}
+}
+
+// WithSharedListener nacos、混合模式下，dataID和group已被当前实例注册时共享已有的监听，不返回错误
+//  配置变化时，共享监听的所有配置都会更新
+func WithSharedListener() RegisterOption {
+	return func(o *RegisterOptions) {
+		o.SharedListener = true
+	}
+}
+
+// WithLazy nacos、混合模式下首次拉取配置失败（或配置不存在）时仍注册成功，服务端可用后在后台填充，可通过Ready等待
+//  nacos模式使用fallback作为初始值，fallback需要与注册的类型一致，为nil时使用只设置了默认值的对象；混合模式使用配置文件的内容
+func WithLazy(fallback interface{}) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Lazy = true
+		o.Fallback = fallback
+	}
+}
+
+// WithDeepCopy 读取配置时返回深拷贝，调用方可以修改返回的对象而不影响其他读取方
+//  未指定时，读取返回当前发布的对象，配置更新时替换为新的对象，原对象不会被修改
+func WithDeepCopy() RegisterOption {
+	return func(o *RegisterOptions) {
+		o.DeepCopy = true
+	}
+}
+
+// WithMerge 混合模式中nacos内容与配置文件内容的合并方式，默认MergeOverlay
+//  配置实现了OnNacosChanged时由其自行解析nacos内容，不使用该选项
+func WithMerge(strategy MergeStrategy) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Merge = strategy
+	}
+}
+
+// NewRegisterOptions 根据可选项生成注册选项
+func NewRegisterOptions(opts ...RegisterOption) *RegisterOptions {
+	o := &RegisterOptions{ProfileEnv: DefaultProfileEnv}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// PublishOption 发布、删除nacos配置时的可选项
+type PublishOption func(*PublishOptions)
+
+// PublishOptions 发布、删除nacos配置的选项集合
+type PublishOptions struct {
+	CAS bool   // 服务端当前内容与当前实例最近一次获取到的内容一致时才写入
+	MD5 string // 服务端当前内容的md5与之一致时才写入
+}
+
+// WithCAS 服务端内容在当前实例最近一次获取后被修改时，返回ErrConflict，避免覆盖其他人的修改
+func WithCAS() PublishOption {
+	return func(o *PublishOptions) {
+		o.CAS = true
+	}
+}
+
+// WithExpectedMD5 服务端当前内容的md5与md5不一致时返回ErrConflict，md5可以通过Explain获取的Origin.Version
+func WithExpectedMD5(md5 string) PublishOption {
+	return func(o *PublishOptions) {
+		o.MD5 = md5
+	}
+}
+
+// NewPublishOptions 根据可选项生成发布选项
+func NewPublishOptions(opts ...PublishOption) *PublishOptions {
+	o := &PublishOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// ConfigOption 创建配置实例时的可选项
+type ConfigOption func(*ConfigOptions)
+
+// ConfigOptions 配置实例的选项集合
+type ConfigOptions struct {
+	Client    INacosClient // 已创建的nacos客户端，指定后无需调用DailNacos
+	Namespace string       // Client访问的namespace
+
+	// 以下选项在DailNacos创建客户端时使用，DailNacos传入的ClientOption优先
+	Logger        Logger         // nacos客户端日志，默认输出到LogDir，WithLogStdout(true)时同时输出到标准输出
+	LogDir        string         // nacos客户端日志目录
+	CacheDir      string         // nacos配置缓存目录，断网时GetConfig读取缓存
+	ClientOptions []ClientOption // 其他nacos客户端选项，e.g. WithLogLevel("info")
+
+	ProbeDataID string // 探测nacos服务端时查询的dataID，只需要读权限
+	ProbeGroup  string // 探测nacos服务端时查询的group
+
+	Retry    Backoff // DailNacos及注册时首次拉取配置失败的重试策略，默认不重试
+	LazyDial bool    // 服务端不可用时DailNacos不返回错误（鉴权失败除外），之后可通过Health获取连接状态
+
+	SnapshotDir string // 保存nacos配置快照的目录，服务端不可用时使用快照启动
+}
+
+// WithNacosClient 使用已创建的nacos客户端，e.g. 共享的客户端、包装过的客户端、configtest.NewClient()
+func WithNacosClient(client INacosClient, namespace string) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.Client = client
+		o.Namespace = namespace
+	}
+}
+
+// WithLogger DailNacos创建的客户端使用自定义日志
+func WithLogger(l Logger) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.Logger = l
+	}
+}
+
+// WithLogDir DailNacos创建的客户端的日志目录，默认为/tmp/nacos/log
+func WithLogDir(dir string) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.LogDir = dir
+	}
+}
+
+// WithCacheDir DailNacos创建的客户端的缓存目录，默认为/tmp/nacos/cache
+func WithCacheDir(dir string) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.CacheDir = dir
+	}
+}
+
+// WithClientOptions DailNacos创建客户端时默认使用的选项
+func WithClientOptions(opts ...ClientOption) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.ClientOptions = append(o.ClientOptions, opts...)
+	}
+}
+
+// WithProbe 探测nacos服务端时查询的dataID和group，不存在时也可以完成探测
+//  默认为DefaultProbeDataID、DefaultProbeGroup
+func WithProbe(dataID, group string) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.ProbeDataID = dataID
+		o.ProbeGroup = group
+	}
+}
+
+// WithRetry DailNacos及注册时首次拉取配置失败的重试策略，鉴权失败、配置不存在时不重试
+//  e.g. WithRetry(Backoff{Attempts: 5, Initial: 500 * time.Millisecond, Max: 5 * time.Second, Jitter: 0.2})
+func WithRetry(b Backoff) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.Retry = b
+	}
+}
+
+// WithLazyDial 重试后服务端仍不可用时DailNacos不返回错误，客户端在后台连接
+//  配合注册时的WithLazy使用，服务端不可用时也可以完成启动
+func WithLazyDial() ConfigOption {
+	return func(o *ConfigOptions) {
+		o.LazyDial = true
+	}
+}
+
+// WithSnapshotDir 从nacos获取到配置后保存到dir，注册时服务端不可用则使用快照，Health中标记为Stale
+//  快照文件为dir/namespace/group/dataID.json，包含内容摘要及获取时间
+func WithSnapshotDir(dir string) ConfigOption {
+	return func(o *ConfigOptions) {
+		o.SnapshotDir = dir
+	}
+}
+
+// NewConfigOptions 根据可选项生成配置实例选项
+func NewConfigOptions(opts ...ConfigOption) *ConfigOptions {
+	o := &ConfigOptions{ProbeDataID: DefaultProbeDataID, ProbeGroup: DefaultProbeGroup}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}